@@ -5,15 +5,49 @@ import (
 )
 
 var (
-	apiKeyField       = field.StringField("api-key", field.WithDescription(`API Key`), field.WithRequired(true))
-	sourceField       = field.StringField("source", field.WithDescription(`Source`), field.WithRequired(true))
-	limitCoursesField = field.StringSliceField("limited-courses", field.WithDescription(`Limit imported sources to a specific list by Course ID`), field.WithRequired(false))
+	apiKeyField            = field.StringField("api-key", field.WithDescription(`API Key`), field.WithRequired(true))
+	sourceField            = field.StringField("source", field.WithDescription(`Source`), field.WithRequired(true))
+	limitCoursesField      = field.StringSliceField("limited-courses", field.WithDescription(`Limit imported sources to a specific list by Course ID`), field.WithRequired(false))
+	enableModulesField     = field.BoolField("enable-modules", field.WithDescription(`Sync the modules of each course and the per-user module results`), field.WithRequired(false))
+	baseURLField           = field.StringField("base-url", field.WithDescription(`Litmos API base URL, or one of the region presets us, eu and au`), field.WithDefaultValue("us"), field.WithRequired(false))
+	pageSizeField          = field.IntField("page-size", field.WithDescription(`Number of items requested per page from the Litmos API, up to 1000`), field.WithDefaultValue(500), field.WithRequired(false))
+	requestsPerMinuteField = field.IntField("requests-per-minute", field.WithDescription(`Maximum number of requests sent to the Litmos API per minute, -1 disables the limit`), field.WithDefaultValue(100), field.WithRequired(false))
+	courseAccessTillField  = field.StringField("course-access-till", field.WithDescription(`Limit the access of users assigned to a course, as an end date such as 2025-12-31 or a duration such as 90d`), field.WithRequired(false))
+	courseDueInField       = field.StringField("course-due-in", field.WithDescription(`Set the due date of course assignments to this long after the assignment, such as 30d`), field.WithRequired(false))
+	courseSendMessageField = field.BoolField("course-send-message", field.WithDescription(`Have Litmos email learners about course assignments`), field.WithRequired(false))
+	enrichUsersField       = field.BoolField("enrich-users", field.WithDescription(`Fetch the full Litmos record of each user, such as job title, manager, employee ID and last login`), field.WithRequired(false))
+	userDetailLimitField   = field.IntField("user-detail-limit", field.WithDescription(`Maximum number of users enriched per sync with --enrich-users or --custom-field-mappings, -1 disables the limit`), field.WithDefaultValue(5000), field.WithRequired(false))
+	customFieldsField      = field.StringSliceField("custom-field-mappings", field.WithDescription(`Copy Litmos custom user fields into the user profile, as <custom field>=<profile key>[:<type>] with type string, int, float, bool or date`), field.WithRequired(false))
+	userProfileKeysField   = field.StringSliceField("user-profile-keys", field.WithDescription(`Only sync these keys of the user profile besides access_level, and leave out the course creator`), field.WithRequired(false))
+	userIdentityField      = field.StringField("user-identity", field.WithDescription(`Replace the email and display name of users with their login ("login"), or those and the login with a stable hash ("hash")`), field.WithRequired(false))
+	userIdentityKeyField   = field.StringField("user-identity-hash-key", field.WithDescription(`Secret key of the hash used by --user-identity=hash, required with it`), field.WithRequired(false))
+	excludeInactiveField   = field.BoolField("exclude-inactive-users", field.WithDescription(`Do not sync deactivated users`), field.WithRequired(false))
+	accessLevelsField      = field.StringSliceField("user-access-levels", field.WithDescription(`Only sync users with one of these access levels: Learner, Team Leader, Administrator or Account Owner`), field.WithRequired(false))
+	brandsField            = field.StringSliceField("user-brands", field.WithDescription(`Only sync users of one of these brands`), field.WithRequired(false))
+	maxRetriesField        = field.IntField("max-retries", field.WithDescription(`Maximum number of times a read that failed with a transient error is retried, -1 disables retries`), field.WithDefaultValue(3), field.WithRequired(false))
 )
 
 var configFields = []field.SchemaField{
 	apiKeyField,
 	sourceField,
 	limitCoursesField,
+	enableModulesField,
+	baseURLField,
+	pageSizeField,
+	requestsPerMinuteField,
+	maxRetriesField,
+	courseAccessTillField,
+	courseDueInField,
+	courseSendMessageField,
+	enrichUsersField,
+	userDetailLimitField,
+	customFieldsField,
+	userProfileKeysField,
+	userIdentityField,
+	userIdentityKeyField,
+	excludeInactiveField,
+	accessLevelsField,
+	brandsField,
 }
 
 var configRelations = []field.SchemaFieldRelationship{}
@@ -38,10 +38,28 @@ func main() {
 func getConnector(ctx context.Context, v *viper.Viper) (types.ConnectorServer, error) {
 	l := ctxzap.Extract(ctx)
 
-	apiKey := v.GetString(apiKeyField.FieldName)
-	source := v.GetString(sourceField.FieldName)
-	limitCourses := v.GetStringSlice(limitCoursesField.FieldName)
-	cb, err := connector.New(ctx, apiKey, source, limitCourses)
+	cb, err := connector.New(ctx, connector.Config{
+		APIKey:               v.GetString(apiKeyField.FieldName),
+		Source:               v.GetString(sourceField.FieldName),
+		LimitCourses:         v.GetStringSlice(limitCoursesField.FieldName),
+		EnableModules:        v.GetBool(enableModulesField.FieldName),
+		BaseURL:              v.GetString(baseURLField.FieldName),
+		PageSize:             v.GetInt(pageSizeField.FieldName),
+		RequestsPerMinute:    v.GetInt(requestsPerMinuteField.FieldName),
+		MaxRetries:           v.GetInt(maxRetriesField.FieldName),
+		CourseAccessTill:     v.GetString(courseAccessTillField.FieldName),
+		CourseDueIn:          v.GetString(courseDueInField.FieldName),
+		CourseSendMessage:    v.GetBool(courseSendMessageField.FieldName),
+		EnrichUsers:          v.GetBool(enrichUsersField.FieldName),
+		UserDetailLimit:      v.GetInt(userDetailLimitField.FieldName),
+		CustomFieldMappings:  v.GetStringSlice(customFieldsField.FieldName),
+		UserProfileKeys:      v.GetStringSlice(userProfileKeysField.FieldName),
+		UserIdentity:         v.GetString(userIdentityField.FieldName),
+		UserIdentityHashKey:  v.GetString(userIdentityKeyField.FieldName),
+		ExcludeInactiveUsers: v.GetBool(excludeInactiveField.FieldName),
+		UserAccessLevels:     v.GetStringSlice(accessLevelsField.FieldName),
+		UserBrands:           v.GetStringSlice(brandsField.FieldName),
+	})
 	if err != nil {
 		l.Error("error creating connector", zap.Error(err))
 		return nil, err
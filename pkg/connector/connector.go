@@ -2,7 +2,10 @@ package connector
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"strings"
+	"time"
 
 	"github.com/conductorone/baton-litmos/pkg/litmos"
 
@@ -10,20 +13,29 @@ import (
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
 	mapset "github.com/deckarep/golang-set/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 type LitmosConnector struct {
-	client        litmos.Client
-	limitCourses  mapset.Set[string]
-	enableModules bool
+	client           litmos.Client
+	limitCourses     mapset.Set[string]
+	enableModules    bool
+	courseAssignment courseAssignment
+	userDetails      *userDetails
+	userSettings     userSettings
+	userFilter       *userFilter
 }
 
 // ResourceSyncers returns a ResourceSyncer for each resource type that should be synced from the upstream service.
 func (d *LitmosConnector) ResourceSyncers(ctx context.Context) []connectorbuilder.ResourceSyncer {
 	rv := []connectorbuilder.ResourceSyncer{
-		newUserBuilder(d.client),
-		newTeamBuilder(d.client),
-		newCourseBuilder(d.client, d.limitCourses, d.enableModules),
+		newUserBuilder(d.client, d.userDetails, d.userSettings, d.userFilter),
+		newTeamBuilder(d.client, d.limitCourses, d.userSettings, d.userFilter),
+		newRoleBuilder(d.client),
+		newCourseBuilder(d.client, d.limitCourses, d.enableModules, d.courseAssignment, d.userSettings.minimizing(), d.userFilter),
+		newLearningPathBuilder(d.client, d.userFilter),
 	}
 	if d.enableModules {
 		rv = append(rv, newModuleBuilder(d.client))
@@ -39,29 +51,173 @@ func (d *LitmosConnector) Asset(ctx context.Context, asset *v2.AssetRef) (string
 
 // Metadata returns metadata about the connector.
 func (d *LitmosConnector) Metadata(ctx context.Context) (*v2.ConnectorMetadata, error) {
+	profile, err := structpb.NewStruct(map[string]interface{}{
+		"account_creation_schema": accountCreationSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return &v2.ConnectorMetadata{
 		DisplayName: "Litmos Baton Connector",
 		Description: "A Baton connector for Litmos",
+		Profile:     profile,
 	}, nil
 }
 
 // Validate is called to ensure that the connector is properly configured. It should exercise any API credentials
 // to be sure that they are valid.
 func (d *LitmosConnector) Validate(ctx context.Context) (annotations.Annotations, error) {
+	err := d.client.CheckAccess(ctx)
+	if err != nil {
+		switch status.Code(err) {
+		case codes.Unauthenticated:
+			return nil, status.Error(codes.Unauthenticated, "litmos-connector: Litmos rejected the api-key, check the --api-key value")
+		case codes.PermissionDenied:
+			return nil, status.Error(codes.PermissionDenied, "litmos-connector: the api-key is not allowed to read users, check the --api-key and --source values")
+		default:
+			return nil, fmt.Errorf("litmos-connector: failed to validate credentials: %w", err)
+		}
+	}
+
+	if d.limitCourses != nil {
+		for _, courseId := range d.limitCourses.ToSlice() {
+			_, err := d.client.GetCourse(ctx, courseId)
+			if err != nil {
+				if isNotFound(err) {
+					return nil, status.Errorf(codes.InvalidArgument, "litmos-connector: course %s from --limited-courses does not exist", courseId)
+				}
+				return nil, fmt.Errorf("litmos-connector: failed to get course %s from --limited-courses: %w", courseId, err)
+			}
+		}
+	}
+
 	return nil, nil
 }
 
+// Config holds the settings the connector is created with.
+type Config struct {
+	APIKey        string
+	Source        string
+	LimitCourses  []string
+	EnableModules bool
+	// BaseURL is the Litmos API base URL or a region preset, see litmos.ParseBaseURL.
+	BaseURL  string
+	PageSize int
+	// RequestsPerMinute caps the request rate of the client, a negative value disables the limit.
+	RequestsPerMinute int
+	// MaxRetries caps how often a read failing with a transient error is retried, a negative value disables retries.
+	MaxRetries int
+	// CourseAccessTill limits the access of users assigned to a course, as an end date or a duration such as 90d.
+	CourseAccessTill string
+	// CourseDueIn sets the due date of course assignments relative to the assignment, such as 30d.
+	CourseDueIn string
+	// CourseSendMessage has Litmos email learners about course assignments.
+	CourseSendMessage bool
+	// EnrichUsers fetches the full record of each synced user, up to UserDetailLimit users per sync. A negative limit
+	// does not cap the number of requests.
+	EnrichUsers     bool
+	UserDetailLimit int
+	// CustomFieldMappings copy custom user fields into the profile, as <custom field>=<profile key>[:<type>]. Mapping
+	// a field turns on EnrichUsers, custom fields are only returned with the full record. Users past UserDetailLimit
+	// are synced without custom fields, and each of them is logged.
+	CustomFieldMappings []string
+	// UserProfileKeys lists the user profile keys that are synced, all keys are synced when empty.
+	UserProfileKeys []string
+	// UserIdentity replaces the email and display name of users with the login ("login"), or those and the login with a
+	// stable hash ("hash").
+	UserIdentity string
+	// UserIdentityHashKey keys the hash of the "hash" identity and is required with it.
+	UserIdentityHashKey string
+	// ExcludeInactiveUsers, UserAccessLevels and UserBrands limit the synced users. Grants of users that are not
+	// synced are dropped. Access levels and brands are compared case-insensitively, and access levels must be Litmos
+	// access levels such as Learner.
+	ExcludeInactiveUsers bool
+	UserAccessLevels     []string
+	UserBrands           []string
+}
+
 // New returns a new instance of the connector.
-func New(ctx context.Context, apiKey, source string, limitCourses []string) (*LitmosConnector, error) {
-	cli, err := litmos.NewClient(ctx, apiKey, source)
+func New(ctx context.Context, cfg Config) (*LitmosConnector, error) {
+	accessTill, err := parseAccessTill(cfg.CourseAccessTill)
+	if err != nil {
+		return nil, err
+	}
+	if accessTill.expired(time.Now()) {
+		return nil, fmt.Errorf("invalid course access limit %q: the date has passed", cfg.CourseAccessTill)
+	}
+	customFields, err := parseCustomFieldMappings(cfg.CustomFieldMappings)
+	if err != nil {
+		return nil, err
+	}
+	userIdentity := strings.ToLower(strings.TrimSpace(cfg.UserIdentity))
+	switch userIdentity {
+	case userIdentityKeep, userIdentityLogin, userIdentityHash:
+	default:
+		return nil, fmt.Errorf("invalid user identity %q: must be login or hash", cfg.UserIdentity)
+	}
+	if userIdentity == userIdentityHash && cfg.UserIdentityHashKey == "" {
+		return nil, fmt.Errorf("invalid user identity %q: a user identity hash key is required", cfg.UserIdentity)
+	}
+	names := make([]string, 0, len(accessLevels))
+	for _, level := range accessLevels {
+		names = append(names, level.Name)
+	}
+	for _, name := range cfg.UserAccessLevels {
+		if !containsFold(names, name) {
+			return nil, fmt.Errorf("invalid user access level %q: must be one of %s", name, strings.Join(names, ", "))
+		}
+	}
+	var dueIn time.Duration
+	if cfg.CourseDueIn != "" {
+		dueIn, err = parseDuration(cfg.CourseDueIn)
+		if err != nil {
+			return nil, fmt.Errorf("invalid course due date: %w", err)
+		}
+	}
+
+	cli, err := litmos.NewClient(
+		ctx,
+		cfg.APIKey,
+		cfg.Source,
+		litmos.WithBaseURL(cfg.BaseURL),
+		litmos.WithPageSize(cfg.PageSize),
+		litmos.WithRequestsPerMinute(cfg.RequestsPerMinute),
+		litmos.WithMaxRetries(cfg.MaxRetries),
+	)
 	if err != nil {
 		return nil, err
 	}
 	lc := &LitmosConnector{
-		client: *cli,
+		client:        *cli,
+		enableModules: cfg.EnableModules,
+		courseAssignment: courseAssignment{
+			accessTill:  accessTill,
+			dueIn:       dueIn,
+			sendMessage: cfg.CourseSendMessage,
+		},
+		userSettings: userSettings{
+			customFields: customFields,
+			identity:     userIdentity,
+			hashKey:      []byte(cfg.UserIdentityHashKey),
+		},
+	}
+	if len(cfg.UserProfileKeys) > 0 {
+		lc.userSettings.profileKeys = mapset.NewSet(cfg.UserProfileKeys...)
+	}
+	if cfg.EnrichUsers || len(customFields) > 0 {
+		lc.userDetails = newUserDetails(lc.client, cfg.UserDetailLimit, len(customFields) > 0)
+	}
+	if cfg.ExcludeInactiveUsers || len(cfg.UserAccessLevels) > 0 || len(cfg.UserBrands) > 0 {
+		lc.userFilter = &userFilter{
+			client:          lc.client,
+			excludeInactive: cfg.ExcludeInactiveUsers,
+			accessLevels:    cfg.UserAccessLevels,
+			brands:          cfg.UserBrands,
+		}
 	}
-	if len(limitCourses) > 0 {
-		lc.limitCourses = mapset.NewSet(limitCourses...)
+	if len(cfg.LimitCourses) > 0 {
+		lc.limitCourses = mapset.NewSet(cfg.LimitCourses...)
 	}
 	return lc, nil
 }
@@ -3,6 +3,10 @@ package connector
 import (
 	"context"
 	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/conductorone/baton-litmos/pkg/litmos"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
@@ -12,24 +16,93 @@ import (
 	"github.com/conductorone/baton-sdk/pkg/types/grant"
 	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
 	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
 const assignedEntitlement = "assigned"
 const completedEntitlement = "completed"
 const inProgressEntitlement = "in_progress"
+const overdueEntitlement = "overdue"
+const complianceExpiredEntitlement = "compliance_expired"
 
 type courseBuilder struct {
 	client        litmos.Client
 	limitCourses  mapset.Set[string]
 	enableModules bool
+	// now returns the current time. It is read once at the start of each sync, and enrollments are compared against
+	// that time to find overdue and expired training, so every page of a sync agrees on it.
+	now         func() time.Time
+	assignment  courseAssignment
+	minimizePII bool
+	userFilter  *userFilter
+
+	mu       sync.Mutex
+	syncedAt time.Time
+}
+
+// courseAssignment holds the settings for enrollments made by the connector.
+type courseAssignment struct {
+	// accessTill limits how long users assigned to a course keep access, see parseAccessTill.
+	accessTill accessTill
+	// dueIn is the time from the assignment until the course is due, zero leaves the due date unset.
+	dueIn time.Duration
+	// sendMessage has Litmos email the learner about the assignment.
+	sendMessage bool
+}
+
+// accessTill is either a fixed end date or a duration counted from the time of the assignment. The zero value does not
+// limit access.
+type accessTill struct {
+	date     time.Time
+	duration time.Duration
+}
+
+// parseAccessTill parses an end date (2006-01-02 or RFC 3339) or a duration such as 90d.
+func parseAccessTill(value string) (accessTill, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return accessTill{}, nil
+	}
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return accessTill{date: date}, nil
+	}
+	if date, err := time.Parse(time.RFC3339, value); err == nil {
+		return accessTill{date: date}, nil
+	}
+	duration, err := parseDuration(value)
+	if err != nil {
+		return accessTill{}, fmt.Errorf("invalid course access limit %q: must be a date such as 2025-12-31 or a duration such as 90d", value)
+	}
+	return accessTill{duration: duration}, nil
+}
+
+// expired reports whether a fixed end date is already past at now. Assigning a course with such a limit would grant no
+// access at all.
+func (a accessTill) expired(now time.Time) bool {
+	return !a.date.IsZero() && !a.date.After(now)
+}
+
+// at returns the end of access for an assignment made at now.
+func (a accessTill) at(now time.Time) (litmos.Time, error) {
+	switch {
+	case a.expired(now):
+		return litmos.Time{}, fmt.Errorf("litmos-connector: course access limit %s has passed", a.date.Format(time.RFC3339))
+	case !a.date.IsZero():
+		return litmos.NewTime(a.date), nil
+	case a.duration > 0:
+		return litmos.NewTime(now.Add(a.duration)), nil
+	default:
+		return litmos.Time{}, nil
+	}
 }
 
 func (o *courseBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
 	return courseResourceType
 }
 
-func courseResource(ctx context.Context, course *litmos.Course, parentResourceID *v2.ResourceId, enableModules bool) (*v2.Resource, error) {
+func courseResource(ctx context.Context, course *litmos.Course, parentResourceID *v2.ResourceId, enableModules bool, minimizePII bool) (*v2.Resource, error) {
 	resourceOptions := []rs.ResourceOption{
 		rs.WithParentResourceID(parentResourceID),
 	}
@@ -43,20 +116,24 @@ func courseResource(ctx context.Context, course *litmos.Course, parentResourceID
 		"Id":                        course.Id,
 		"Code":                      course.Code,
 		"Name":                      course.Name,
-		"Active":                    course.Active,
-		"ForSale":                   course.ForSale,
+		"Active":                    course.Active.Value(),
+		"ForSale":                   course.ForSale.Value(),
 		"OriginalId":                course.OriginalId,
 		"Description":               course.Description,
 		"EcommerceShortDescription": course.EcommerceShortDescription,
 		"EcommerceLongDescription":  course.EcommerceLongDescription,
 		"CourseCodeForBulkImport":   course.CourseCodeForBulkImport,
 		"Price":                     course.Price,
-		"AccessTillDate":            course.AccessTillDate,
+		"AccessTillDate":            course.AccessTillDate.Value(),
 		"AccessTillDays":            course.AccessTillDays,
-		"CourseTeamLibrary":         course.CourseTeamLibrary,
+		"CourseTeamLibrary":         course.CourseTeamLibrary.Value(),
 		"CreatedBy":                 course.CreatedBy,
 		"SeqId":                     course.SeqId,
 	}
+	// CreatedBy names a person, it is left out when user profiles are minimized.
+	if minimizePII {
+		delete(profile, "CreatedBy")
+	}
 	p, err := structpb.NewStruct(profile)
 	if err == nil {
 		resourceOptions = append(resourceOptions, rs.WithAnnotation(p))
@@ -76,6 +153,13 @@ func courseResource(ctx context.Context, course *litmos.Course, parentResourceID
 }
 
 func (o *courseBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	// The first page starts a new sync.
+	if pToken.Token == "" {
+		o.mu.Lock()
+		o.syncedAt = o.now()
+		o.mu.Unlock()
+	}
+
 	if o.limitCourses != nil {
 		resources := make([]*v2.Resource, 0, len(o.limitCourses.ToSlice()))
 		for _, courseId := range o.limitCourses.ToSlice() {
@@ -83,7 +167,7 @@ func (o *courseBuilder) List(ctx context.Context, parentResourceID *v2.ResourceI
 			if err != nil {
 				return nil, "", nil, err
 			}
-			resource, err := courseResource(ctx, course, parentResourceID, o.enableModules)
+			resource, err := courseResource(ctx, course, parentResourceID, o.enableModules, o.minimizePII)
 			if err != nil {
 				return nil, "", nil, err
 			}
@@ -92,27 +176,27 @@ func (o *courseBuilder) List(ctx context.Context, parentResourceID *v2.ResourceI
 		return resources, "", nil, nil
 	}
 
-	courses, nextPageToken, err := o.client.ListCourses(ctx, pToken)
+	courses, nextPageToken, rateLimit, err := o.client.ListCourses(ctx, pToken)
 	if err != nil {
 		return nil, nextPageToken, nil, err
 	}
 
 	resources := make([]*v2.Resource, 0, len(courses))
 	for _, course := range courses {
-		resource, err := courseResource(ctx, &course, parentResourceID, o.enableModules)
+		resource, err := courseResource(ctx, &course, parentResourceID, o.enableModules, o.minimizePII)
 		if err != nil {
 			return nil, "", nil, err
 		}
 		resources = append(resources, resource)
 	}
-	return resources, nextPageToken, nil, nil
+	return resources, nextPageToken, rateLimitAnnotations(rateLimit), nil
 }
 
 // Entitlements always returns an empty slice for users.
 func (o *courseBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
 	var rv []*v2.Entitlement
 	assignedOptions := []entitlement.EntitlementOption{
-		entitlement.WithGrantableTo(userResourceType),
+		entitlement.WithGrantableTo(userResourceType, teamResourceType),
 		entitlement.WithDisplayName(fmt.Sprintf("Course %s %s", resource.DisplayName, assignedEntitlement)),
 		entitlement.WithDescription(fmt.Sprintf("Assigned course %s in Litmos", resource.DisplayName)),
 	}
@@ -126,6 +210,16 @@ func (o *courseBuilder) Entitlements(_ context.Context, resource *v2.Resource, _
 		entitlement.WithDisplayName(fmt.Sprintf("Course %s %s", resource.DisplayName, inProgressEntitlement)),
 		entitlement.WithDescription(fmt.Sprintf("In progress course %s in Litmos", resource.DisplayName)),
 	}
+	overdueOptions := []entitlement.EntitlementOption{
+		entitlement.WithGrantableTo(userResourceType),
+		entitlement.WithDisplayName(fmt.Sprintf("Course %s %s", resource.DisplayName, overdueEntitlement)),
+		entitlement.WithDescription(fmt.Sprintf("Not completed course %s in Litmos by its due date", resource.DisplayName)),
+	}
+	complianceExpiredOptions := []entitlement.EntitlementOption{
+		entitlement.WithGrantableTo(userResourceType),
+		entitlement.WithDisplayName(fmt.Sprintf("Course %s %s", resource.DisplayName, complianceExpiredEntitlement)),
+		entitlement.WithDescription(fmt.Sprintf("Compliance for course %s in Litmos has expired", resource.DisplayName)),
+	}
 
 	entitlements := []*v2.Entitlement{
 		entitlement.NewAssignmentEntitlement(
@@ -143,12 +237,23 @@ func (o *courseBuilder) Entitlements(_ context.Context, resource *v2.Resource, _
 			inProgressEntitlement,
 			inProgressOptions...,
 		),
+		entitlement.NewAssignmentEntitlement(
+			resource,
+			overdueEntitlement,
+			overdueOptions...,
+		),
+		entitlement.NewAssignmentEntitlement(
+			resource,
+			complianceExpiredEntitlement,
+			complianceExpiredOptions...,
+		),
 	}
 	rv = append(rv, entitlements...)
 	return rv, "", nil, nil
 }
 
-// Grants always returns an empty slice for users since they don't have any entitlements.
+// Grants pages through the users enrolled in the course. With modules enabled, the module results of each user are
+// fetched along with the enrollment.
 func (o *courseBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
 	if o.limitCourses != nil {
 		if !o.limitCourses.Contains(resource.Id.Resource) {
@@ -156,47 +261,198 @@ func (o *courseBuilder) Grants(ctx context.Context, resource *v2.Resource, pToke
 		}
 	}
 
-	users, nextPageToken, err := o.client.ListCourseUsers(ctx, pToken, resource.Id.Resource)
+	users, nextPageToken, rateLimit, err := o.client.ListCourseUsers(ctx, pToken, resource.Id.Resource)
 	if err != nil {
 		return nil, nextPageToken, nil, err
 	}
 
+	now := o.syncTime()
 	rv := make([]*v2.Grant, 0, len(users))
 	for _, user := range users {
+		allowed, err := o.userFilter.AllowsID(ctx, user.Id)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		if !allowed {
+			continue
+		}
 		rID, err := rs.NewResourceID(userResourceType, user.Id)
 		if err != nil {
 			return rv, nextPageToken, nil, err
 		}
 
-		grants := []*v2.Grant{grant.NewGrant(
-			resource,
-			assignedEntitlement,
-			rID,
-		)}
-		if user.Completed {
+		// Progress is attached to every grant of the enrollment so reviewers do not have to look it up in Litmos.
+		progress := map[string]interface{}{
+			"percentage_complete": user.PercentageComplete.Value(),
+			"due_date":            user.DueDate.Value(),
+			"compliant_till":      user.CompliantTill.Value(),
+			"access_till_date":    user.AccessTillDate.Value(),
+		}
+		metadata := grant.WithGrantMetadata(progress)
+
+		// Litmos removes the course once the access limit has passed, so the assignment expires with it.
+		var grants []*v2.Grant
+		if !user.AccessTillDate.Valid || now.Before(user.AccessTillDate.Time) {
+			assigned := maps.Clone(progress)
+			assigned["expires_at"] = user.AccessTillDate.Value()
+			grants = append(grants, grant.NewGrant(
+				resource,
+				assignedEntitlement,
+				rID,
+				grant.WithGrantMetadata(assigned),
+			))
+		}
+
+		// A completion only counts while it is compliant, a recertification that lapsed is reported as expired instead.
+		complianceExpired := user.CompliantTill.Valid && now.After(user.CompliantTill.Time)
+		switch {
+		case complianceExpired:
+			grants = append(grants, grant.NewGrant(
+				resource,
+				complianceExpiredEntitlement,
+				rID,
+				metadata,
+			))
+		case user.Completed.Bool:
 			grants = append(grants, grant.NewGrant(
 				resource,
 				completedEntitlement,
 				rID,
+				metadata,
 			))
-		} else {
+		default:
 			grants = append(grants, grant.NewGrant(
 				resource,
 				inProgressEntitlement,
 				rID,
+				metadata,
+			))
+		}
+		if !user.Completed.Bool && user.DueDate.Valid && now.After(user.DueDate.Time) {
+			grants = append(grants, grant.NewGrant(
+				resource,
+				overdueEntitlement,
+				rID,
+				metadata,
 			))
 		}
 
+		if o.enableModules {
+			modules, err := moduleGrants(ctx, o.client, resource, rID)
+			if err != nil {
+				return nil, "", nil, err
+			}
+			grants = append(grants, modules...)
+		}
+
 		rv = append(rv, grants...)
 	}
 
-	return rv, nextPageToken, nil, nil
+	return rv, nextPageToken, rateLimitAnnotations(rateLimit), nil
+}
+
+// Grant enrolls the principal in the course, or assigns the course to every member of a team. Only the assigned
+// entitlement can be provisioned, completion is driven by the learner.
+func (o *courseBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	if slug := entitlementSlug(entitlement); slug != assignedEntitlement {
+		return nil, fmt.Errorf("litmos-connector: entitlement %s cannot be provisioned", slug)
+	}
+
+	courseId := entitlement.Resource.Id.Resource
+
+	var err error
+	switch principal.Id.ResourceType {
+	case userResourceType.Id:
+		now := o.now()
+		var accessTillDate litmos.Time
+		accessTillDate, err = o.assignment.accessTill.at(now)
+		if err != nil {
+			return nil, err
+		}
+		assignment := &litmos.CourseAssignment{
+			CourseId:       courseId,
+			AccessTillDate: accessTillDate,
+			SendMessage:    o.assignment.sendMessage,
+		}
+		if o.assignment.dueIn > 0 {
+			assignment.DueDate = litmos.NewTime(now.Add(o.assignment.dueIn))
+		}
+		err = o.client.AssignCourse(ctx, principal.Id.Resource, assignment)
+	case teamResourceType.Id:
+		err = o.client.AssignCourseToTeam(ctx, principal.Id.Resource, courseId)
+	default:
+		l.Warn(
+			"litmos-connector: only users and teams can be assigned to a course",
+			zap.String("principal_type", principal.Id.ResourceType),
+			zap.String("principal_id", principal.Id.Resource),
+		)
+		return nil, fmt.Errorf("litmos-connector: only users and teams can be assigned to a course")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("litmos-connector: failed to assign course: %w", err)
+	}
+
+	return nil, nil
+}
+
+// Revoke unenrolls the principal from the course, or unassigns the course from a team. Users keep a course they are
+// also assigned through one of their teams.
+func (o *courseBuilder) Revoke(ctx context.Context, g *v2.Grant) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	entitlement := g.Entitlement
+	principal := g.Principal
+
+	if slug := entitlementSlug(entitlement); slug != assignedEntitlement {
+		return nil, fmt.Errorf("litmos-connector: entitlement %s cannot be provisioned", slug)
+	}
+
+	courseId := entitlement.Resource.Id.Resource
+
+	var err error
+	switch principal.Id.ResourceType {
+	case userResourceType.Id:
+		err = o.client.RemoveCourseFromUser(ctx, principal.Id.Resource, courseId)
+	case teamResourceType.Id:
+		err = o.client.RemoveCourseFromTeam(ctx, principal.Id.Resource, courseId)
+	default:
+		l.Warn(
+			"litmos-connector: only users and teams can be unassigned from a course",
+			zap.String("principal_type", principal.Id.ResourceType),
+			zap.String("principal_id", principal.Id.Resource),
+		)
+		return nil, fmt.Errorf("litmos-connector: only users and teams can be unassigned from a course")
+	}
+	if err != nil {
+		if isNotFound(err) {
+			return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+		}
+		return nil, fmt.Errorf("litmos-connector: failed to unassign course: %w", err)
+	}
+
+	return nil, nil
+}
+
+// syncTime returns the time the current sync started, or the current time when no courses were listed yet.
+func (o *courseBuilder) syncTime() time.Time {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.syncedAt.IsZero() {
+		return o.now()
+	}
+	return o.syncedAt
 }
 
-func newCourseBuilder(client litmos.Client, limitCourses mapset.Set[string], enableModules bool) *courseBuilder {
+func newCourseBuilder(client litmos.Client, limitCourses mapset.Set[string], enableModules bool, assignment courseAssignment, minimizePII bool, userFilter *userFilter) *courseBuilder {
 	return &courseBuilder{
 		client:        client,
 		limitCourses:  limitCourses,
 		enableModules: enableModules,
+		now:           time.Now,
+		assignment:    assignment,
+		minimizePII:   minimizePII,
+		userFilter:    userFilter,
 	}
 }
@@ -0,0 +1,212 @@
+package connector
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+// grantKeys returns the grants as sorted "<resource id>:<entitlement slug>:<principal id>" strings.
+func grantKeys(grants []*v2.Grant) []string {
+	keys := make([]string, 0, len(grants))
+	for _, g := range grants {
+		keys = append(keys, g.Entitlement.Resource.Id.Resource+":"+entitlementSlug(g.Entitlement)+":"+g.Principal.Id.Resource)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestCourseGrantsFetchModuleResultsOncePerUser(t *testing.T) {
+	moduleResults := `<Course><Id>c1</Id><Modules>
+		<Module><Id>m1</Id><Completed>true</Completed><Passed>true</Passed><PassMark>80</PassMark><Attempt>1</Attempt></Module>
+		<Module><Id>m2</Id><Completed>false</Completed><Passed>false</Passed><PassMark>80</PassMark><Attempt>2</Attempt></Module>
+		<Module><Id>m3</Id><Completed>false</Completed><Passed>false</Passed></Module>
+	</Modules></Course>`
+	api := newFakeAPI(map[string]string{
+		"/v1.svc/courses/c1/users": `<Users>
+			<User><Id>u1</Id><Completed>false</Completed></User>
+			<User><Id>u2</Id><Completed>false</Completed></User>
+		</Users>`,
+		"/v1.svc/users/u1/courses/c1": moduleResults,
+		"/v1.svc/users/u2/courses/c1": moduleResults,
+	})
+	o := newCourseBuilder(newTestClient(t, api), nil, true, courseAssignment{}, false, nil)
+
+	course, err := rs.NewResource("Course", courseResourceType, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grants, _, _, err := o.Grants(context.Background(), course, &pagination.Token{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"/v1.svc/users/u1/courses/c1", "/v1.svc/users/u2/courses/c1"} {
+		if hits := api.Hits(path); hits != 1 {
+			t.Errorf("%s was requested %d times, want 1", path, hits)
+		}
+	}
+
+	want := []string{
+		"c1:assigned:u1", "c1:assigned:u2",
+		"c1:in_progress:u1", "c1:in_progress:u2",
+		"m1:completed:u1", "m1:completed:u2",
+		"m1:passed:u1", "m1:passed:u2",
+		"m2:failed:u1", "m2:failed:u2",
+	}
+	sort.Strings(want)
+	if got := grantKeys(grants); !slices.Equal(got, want) {
+		t.Errorf("grants %v, want %v", got, want)
+	}
+	for _, g := range grants {
+		if g.Entitlement.Resource.Id.ResourceType == moduleResourceType.Id && g.Entitlement.Resource.ParentResourceId.GetResource() != "c1" {
+			t.Errorf("module grant %s is not parented to the course", g.Id)
+		}
+	}
+}
+
+func TestCourseGrantsAgainstClock(t *testing.T) {
+	api := newFakeAPI(map[string]string{
+		"/v1.svc/courses/c1/users": `<Users>
+			<User><Id>on-time</Id><Completed>false</Completed><DueDate>2024-07-01T00:00:00</DueDate></User>
+			<User><Id>overdue</Id><Completed>false</Completed><DueDate>2024-05-31T00:00:00</DueDate></User>
+			<User><Id>completed-late</Id><Completed>true</Completed><DueDate>2024-05-31T00:00:00</DueDate></User>
+			<User><Id>compliant</Id><Completed>true</Completed><CompliantTill>2024-12-31T00:00:00</CompliantTill></User>
+			<User><Id>compliance-expired</Id><Completed>true</Completed><CompliantTill>2024-05-31T00:00:00</CompliantTill><AccessTillDate>2024-12-31T00:00:00</AccessTillDate></User>
+			<User><Id>access-expired</Id><Completed>true</Completed><CompliantTill>2024-05-31T00:00:00</CompliantTill><AccessTillDate>2024-06-01T00:00:00</AccessTillDate></User>
+			<User><Id>no-dates</Id><Completed/></User>
+		</Users>`,
+	})
+	o := newCourseBuilder(newTestClient(t, api), nil, false, courseAssignment{}, false, nil)
+	o.now = func() time.Time { return time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC) }
+
+	course, err := rs.NewResource("Course", courseResourceType, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grants, _, _, err := o.Grants(context.Background(), course, &pagination.Token{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"c1:assigned:on-time", "c1:in_progress:on-time",
+		"c1:assigned:overdue", "c1:in_progress:overdue", "c1:overdue:overdue",
+		"c1:assigned:completed-late", "c1:completed:completed-late",
+		"c1:assigned:compliant", "c1:completed:compliant",
+		"c1:assigned:compliance-expired", "c1:compliance_expired:compliance-expired",
+		"c1:compliance_expired:access-expired",
+		"c1:assigned:no-dates", "c1:in_progress:no-dates",
+	}
+	sort.Strings(want)
+	if got := grantKeys(grants); !slices.Equal(got, want) {
+		t.Errorf("grants %v, want %v", got, want)
+	}
+}
+
+func TestCourseGrantsUseTimeOfSyncStart(t *testing.T) {
+	api := newFakeAPI(map[string]string{
+		"/v1.svc/courses":          `<Courses><Course><Id>c1</Id><Name>Course</Name></Course></Courses>`,
+		"/v1.svc/courses/c1/users": `<Users><User><Id>u1</Id><Completed>false</Completed><DueDate>2024-06-01T00:00:00</DueDate></User></Users>`,
+	})
+	o := newCourseBuilder(newTestClient(t, api), nil, false, courseAssignment{}, false, nil)
+	clock := time.Date(2024, 5, 31, 23, 59, 0, 0, time.UTC)
+	o.now = func() time.Time { return clock }
+
+	ctx := context.Background()
+	courses, _, _, err := o.List(ctx, nil, &pagination.Token{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(courses) != 1 {
+		t.Fatalf("got %d courses, want 1", len(courses))
+	}
+
+	// The due date passes while the sync runs, the course is still reported as on time.
+	clock = clock.Add(time.Hour)
+	grants, _, _, err := o.Grants(ctx, courses[0], &pagination.Token{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"c1:assigned:u1", "c1:in_progress:u1"}
+	if got := grantKeys(grants); !slices.Equal(got, want) {
+		t.Errorf("grants %v, want %v", got, want)
+	}
+
+	// The next sync reads the clock again.
+	courses, _, _, err = o.List(ctx, nil, &pagination.Token{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	grants, _, _, err = o.Grants(ctx, courses[0], &pagination.Token{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"c1:assigned:u1", "c1:in_progress:u1", "c1:overdue:u1"}
+	if got := grantKeys(grants); !slices.Equal(got, want) {
+		t.Errorf("grants %v, want %v", got, want)
+	}
+}
+
+func TestParseAccessTill(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    accessTill
+		wantErr bool
+	}{
+		{value: ""},
+		{value: "2025-12-31", want: accessTill{date: time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)}},
+		{value: "2025-12-31T17:00:00+02:00", want: accessTill{date: time.Date(2025, 12, 31, 15, 0, 0, 0, time.UTC)}},
+		{value: " 90d ", want: accessTill{duration: 90 * 24 * time.Hour}},
+		{value: "720h", want: accessTill{duration: 720 * time.Hour}},
+		{value: "31/12/2025", wantErr: true},
+		{value: "-90d", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseAccessTill(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, want error %t", err, tt.wantErr)
+			}
+			if !got.date.Equal(tt.want.date) || got.duration != tt.want.duration {
+				t.Errorf("parseAccessTill(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCourseAccessLimitInThePast(t *testing.T) {
+	_, err := New(context.Background(), Config{APIKey: "key", Source: "test", CourseAccessTill: "2000-01-01"})
+	if err == nil {
+		t.Error("New accepted a course access limit in the past")
+	}
+
+	// A limit that passes while the connector runs fails the assignment instead of sending it.
+	api := newFakeAPI(map[string]string{"/v1.svc/users/u1/courses": ""})
+	assignment := courseAssignment{accessTill: accessTill{date: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}}
+	o := newCourseBuilder(newTestClient(t, api), nil, false, assignment, false, nil)
+	o.now = func() time.Time { return time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC) }
+
+	course, err := rs.NewResource("Course", courseResourceType, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	principal, err := rs.NewUserResource("ada", userResourceType, "u1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = o.Grant(context.Background(), principal, entitlement.NewAssignmentEntitlement(course, assignedEntitlement))
+	if err == nil {
+		t.Error("Grant assigned the course with an access limit in the past")
+	}
+	if hits := api.Hits("/v1.svc/users/u1/courses"); hits != 0 {
+		t.Errorf("the course was assigned %d times, want 0", hits)
+	}
+}
@@ -0,0 +1,96 @@
+package connector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/conductorone/baton-litmos/pkg/litmos"
+)
+
+// customFieldMapping copies a Litmos custom user field into the user profile.
+type customFieldMapping struct {
+	name       string
+	profileKey string
+	kind       string
+}
+
+// Types a custom field value can be coerced to.
+const (
+	customFieldString = "string"
+	customFieldInt    = "int"
+	customFieldFloat  = "float"
+	customFieldBool   = "bool"
+	customFieldDate   = "date"
+)
+
+// parseCustomFieldMappings parses mappings of the form <custom field name>=<profile key>[:<type>]. The type defaults to
+// string.
+func parseCustomFieldMappings(values []string) ([]customFieldMapping, error) {
+	rv := make([]customFieldMapping, 0, len(values))
+	for _, value := range values {
+		name, target, ok := strings.Cut(value, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid custom field mapping %q: must be <custom field>=<profile key>[:<type>]", value)
+		}
+
+		profileKey, kind, _ := strings.Cut(target, ":")
+		profileKey = strings.TrimSpace(profileKey)
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		if profileKey == "" {
+			return nil, fmt.Errorf("invalid custom field mapping %q: missing profile key", value)
+		}
+		switch kind {
+		case "":
+			kind = customFieldString
+		case customFieldString, customFieldInt, customFieldFloat, customFieldBool, customFieldDate:
+		default:
+			return nil, fmt.Errorf("invalid custom field mapping %q: type must be one of string, int, float, bool and date", value)
+		}
+
+		rv = append(rv, customFieldMapping{name: name, profileKey: profileKey, kind: kind})
+	}
+	return rv, nil
+}
+
+// coerce converts the custom field value to the type of the mapping.
+func (m customFieldMapping) coerce(value string) (interface{}, error) {
+	value = strings.TrimSpace(value)
+	switch m.kind {
+	case customFieldInt:
+		return strconv.ParseInt(value, 10, 64)
+	case customFieldFloat:
+		return strconv.ParseFloat(value, 64)
+	case customFieldBool:
+		return strconv.ParseBool(value)
+	case customFieldDate:
+		t, ok := litmos.ParseTime(value)
+		if !ok {
+			return nil, fmt.Errorf("invalid date %q", value)
+		}
+		return t.UTC().Format(time.RFC3339), nil
+	default:
+		return value, nil
+	}
+}
+
+// customFieldProfile returns the profile values of the mapped custom fields of the user. Fields that are empty or
+// cannot be coerced to their type are left out.
+func customFieldProfile(user *litmos.User, mappings []customFieldMapping) map[string]interface{} {
+	rv := make(map[string]interface{})
+	for _, mapping := range mappings {
+		for _, field := range user.CustomFields {
+			if !strings.EqualFold(strings.TrimSpace(field.Name), mapping.name) || strings.TrimSpace(field.Value) == "" {
+				continue
+			}
+			value, err := mapping.coerce(field.Value)
+			if err != nil {
+				continue
+			}
+			rv[mapping.profileKey] = value
+		}
+	}
+	return rv
+}
@@ -0,0 +1,92 @@
+package connector
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestCustomFieldsMappedIntoProfile(t *testing.T) {
+	userDetail := func(id, costCenter, seats string) string {
+		return `<User><Id>` + id + `</Id><UserName>` + id + `@example.com</UserName><Active>true</Active>
+			<CustomFields>
+				<CustomField><Name>Cost Center</Name><Value>` + costCenter + `</Value></CustomField>
+				<CustomField><Name>Seats</Name><Value>` + seats + `</Value></CustomField>
+				<CustomField><Name>Unmapped</Name><Value>ignored</Value></CustomField>
+			</CustomFields></User>`
+	}
+	api := newFakeAPI(map[string]string{
+		"/v1.svc/users": `<Users>
+			<User><Id>u1</Id><UserName>u1@example.com</UserName><Active>true</Active></User>
+			<User><Id>u2</Id><UserName>u2@example.com</UserName><Active>true</Active></User>
+			<User><Id>u3</Id><UserName>u3@example.com</UserName><Active>true</Active></User>
+		</Users>`,
+		"/v1.svc/users/u1": userDetail("u1", "CC-42", "3"),
+		"/v1.svc/users/u2": userDetail("u2", "CC-7", "not a number"),
+		"/v1.svc/users/u3": userDetail("u3", "CC-9", "1"),
+	})
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	// The detail limit is below the number of users, the third user is synced without custom fields and logged.
+	core, logs := observer.New(zap.WarnLevel)
+	ctx := ctxzap.ToContext(context.Background(), zap.New(core))
+	lc, err := New(ctx, Config{
+		APIKey:              "key",
+		Source:              "test",
+		BaseURL:             srv.URL,
+		RequestsPerMinute:   -1,
+		MaxRetries:          -1,
+		UserDetailLimit:     2,
+		CustomFieldMappings: []string{"Cost Center=cost_center", "Seats=seats:int"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := newUserBuilder(lc.client, lc.userDetails, lc.userSettings, lc.userFilter)
+
+	users, _, _, err := o.List(ctx, nil, &pagination.Token{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 3 {
+		t.Fatalf("got %d users, want 3", len(users))
+	}
+
+	want := map[string]map[string]interface{}{
+		"u1": {"cost_center": "CC-42", "seats": float64(3)},
+		"u2": {"cost_center": "CC-7", "seats": nil},
+		"u3": {"cost_center": nil, "seats": nil},
+	}
+	for _, user := range users {
+		trait, err := rs.GetUserTrait(user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		profile := trait.GetProfile().AsMap()
+		for key, value := range want[user.Id.Resource] {
+			got, ok := profile[key]
+			switch {
+			case value == nil && ok:
+				t.Errorf("%s: %s = %v, want it left out", user.Id.Resource, key, got)
+			case value != nil && got != value:
+				t.Errorf("%s: %s = %v, want %v", user.Id.Resource, key, got, value)
+			}
+		}
+		if _, ok := profile["Unmapped"]; ok {
+			t.Errorf("%s: unmapped custom field is in the profile", user.Id.Resource)
+		}
+	}
+	if hits := api.Hits("/v1.svc/users/u3"); hits != 0 {
+		t.Errorf("details of the third user were requested %d times, want 0", hits)
+	}
+	if logs.FilterField(zap.String("user_id", "u3")).Len() != 1 || logs.FilterField(zap.String("user_id", "u2")).Len() != 0 {
+		t.Errorf("got %v, want the third user logged as synced without custom fields", logs.All())
+	}
+}
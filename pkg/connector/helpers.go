@@ -0,0 +1,53 @@
+package connector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// entitlementSlug returns the name of the entitlement, falling back to the last
+// segment of the entitlement ID (<resource type>:<resource id>:<slug>).
+func entitlementSlug(e *v2.Entitlement) string {
+	if e.Slug != "" {
+		return e.Slug
+	}
+	parts := strings.Split(e.Id, ":")
+	return parts[len(parts)-1]
+}
+
+func isNotFound(err error) bool {
+	return status.Code(err) == codes.NotFound
+}
+
+// rateLimitAnnotations reports the Litmos quota left after the last request of a page.
+func rateLimitAnnotations(rateLimit *v2.RateLimitDescription) annotations.Annotations {
+	annos := annotations.Annotations{}
+	if rateLimit != nil {
+		annos.WithRateLimiting(rateLimit)
+	}
+	return annos
+}
+
+// parseDuration parses a number of days such as 30d, or any duration accepted by time.ParseDuration.
+func parseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return d, nil
+}
@@ -0,0 +1,99 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-litmos/pkg/litmos"
+)
+
+// newTestClient returns a client for a fake Litmos API served by handler. Client-side rate limiting and retries are
+// turned off so tests run fast.
+func newTestClient(t *testing.T, handler http.Handler) litmos.Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	c, err := litmos.NewClient(
+		context.Background(),
+		"key",
+		"test",
+		litmos.WithBaseURL(srv.URL),
+		litmos.WithRequestsPerMinute(-1),
+		litmos.WithMaxRetries(-1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *c
+}
+
+// writeXML sends body as an XML response.
+func writeXML(w http.ResponseWriter, body string) {
+	w.Header().Set("Content-Type", "application/xml")
+	_, _ = w.Write([]byte(body))
+}
+
+// fakeAPI serves canned XML bodies by request path and counts the requests made for each path.
+type fakeAPI struct {
+	mu        sync.Mutex
+	responses map[string]string
+	hits      map[string]int
+}
+
+func newFakeAPI(responses map[string]string) *fakeAPI {
+	return &fakeAPI{responses: responses, hits: make(map[string]int)}
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.hits[r.URL.Path]++
+	body, ok := f.responses[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	writeXML(w, body)
+}
+
+func (f *fakeAPI) Hits(path string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.hits[path]
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "90d", want: 90 * 24 * time.Hour},
+		{value: " 0d ", want: 0},
+		{value: "36h", want: 36 * time.Hour},
+		{value: "1h30m", want: 90 * time.Minute},
+		{value: "-1d", wantErr: true},
+		{value: "-1h", wantErr: true},
+		{value: "1.5d", wantErr: true},
+		{value: "d", wantErr: true},
+		{value: "soon", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseDuration(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, want error %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseDuration(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conductorone/baton-litmos/pkg/litmos"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type learningPathBuilder struct {
+	client     litmos.Client
+	userFilter *userFilter
+}
+
+func (o *learningPathBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return learningPathResourceType
+}
+
+func learningPathResource(ctx context.Context, learningPath *litmos.LearningPath, courses []litmos.Course, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	resourceOptions := []rs.ResourceOption{
+		rs.WithParentResourceID(parentResourceID),
+	}
+
+	courseIds := make([]interface{}, 0, len(courses))
+	for _, course := range courses {
+		courseIds = append(courseIds, course.Id)
+	}
+
+	profile := map[string]interface{}{
+		"Id":                        learningPath.Id,
+		"Name":                      learningPath.Name,
+		"Active":                    learningPath.Active.Value(),
+		"ForSale":                   learningPath.ForSale.Value(),
+		"OriginalId":                learningPath.OriginalId,
+		"Description":               learningPath.Description,
+		"EcommerceShortDescription": learningPath.EcommerceShortDescription,
+		"EcommerceLongDescription":  learningPath.EcommerceLongDescription,
+		"Price":                     learningPath.Price,
+		"AccessTillDate":            learningPath.AccessTillDate.Value(),
+		"AccessTillDays":            learningPath.AccessTillDays,
+		"CourseIds":                 courseIds,
+	}
+	p, err := structpb.NewStruct(profile)
+	if err == nil {
+		resourceOptions = append(resourceOptions, rs.WithAnnotation(p))
+	}
+
+	resource, err := rs.NewResource(
+		learningPath.Name,
+		learningPathResourceType,
+		learningPath.Id,
+		resourceOptions...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// listCourses returns every course bundled in the learning path.
+func (o *learningPathBuilder) listCourses(ctx context.Context, learningPathId string) ([]litmos.Course, error) {
+	var rv []litmos.Course
+	page := &pagination.Token{}
+	for {
+		courses, nextPageToken, _, err := o.client.ListLearningPathCourses(ctx, page, learningPathId)
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, courses...)
+		if nextPageToken == "" {
+			return rv, nil
+		}
+		page.Token = nextPageToken
+	}
+}
+
+func (o *learningPathBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	learningPaths, nextPageToken, rateLimit, err := o.client.ListLearningPaths(ctx, pToken)
+	if err != nil {
+		return nil, nextPageToken, nil, err
+	}
+
+	resources := make([]*v2.Resource, 0, len(learningPaths))
+	for _, learningPath := range learningPaths {
+		courses, err := o.listCourses(ctx, learningPath.Id)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		resource, err := learningPathResource(ctx, &learningPath, courses, parentResourceID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		resources = append(resources, resource)
+	}
+	return resources, nextPageToken, rateLimitAnnotations(rateLimit), nil
+}
+
+func (o *learningPathBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	var rv []*v2.Entitlement
+	assignedOptions := []entitlement.EntitlementOption{
+		entitlement.WithGrantableTo(userResourceType),
+		entitlement.WithDisplayName(fmt.Sprintf("Learning Path %s %s", resource.DisplayName, assignedEntitlement)),
+		entitlement.WithDescription(fmt.Sprintf("Assigned learning path %s in Litmos", resource.DisplayName)),
+	}
+	completedOptions := []entitlement.EntitlementOption{
+		entitlement.WithGrantableTo(userResourceType),
+		entitlement.WithDisplayName(fmt.Sprintf("Learning Path %s %s", resource.DisplayName, completedEntitlement)),
+		entitlement.WithDescription(fmt.Sprintf("Completed learning path %s in Litmos", resource.DisplayName)),
+	}
+	inProgressOptions := []entitlement.EntitlementOption{
+		entitlement.WithGrantableTo(userResourceType),
+		entitlement.WithDisplayName(fmt.Sprintf("Learning Path %s %s", resource.DisplayName, inProgressEntitlement)),
+		entitlement.WithDescription(fmt.Sprintf("In progress learning path %s in Litmos", resource.DisplayName)),
+	}
+
+	entitlements := []*v2.Entitlement{
+		entitlement.NewAssignmentEntitlement(
+			resource,
+			assignedEntitlement,
+			assignedOptions...,
+		),
+		entitlement.NewAssignmentEntitlement(
+			resource,
+			completedEntitlement,
+			completedOptions...,
+		),
+		entitlement.NewAssignmentEntitlement(
+			resource,
+			inProgressEntitlement,
+			inProgressOptions...,
+		),
+	}
+	rv = append(rv, entitlements...)
+	return rv, "", nil, nil
+}
+
+func (o *learningPathBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	users, nextPageToken, rateLimit, err := o.client.ListLearningPathUsers(ctx, pToken, resource.Id.Resource)
+	if err != nil {
+		return nil, nextPageToken, nil, err
+	}
+
+	rv := make([]*v2.Grant, 0, len(users))
+	for _, user := range users {
+		allowed, err := o.userFilter.AllowsID(ctx, user.Id)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		if !allowed {
+			continue
+		}
+		rID, err := rs.NewResourceID(userResourceType, user.Id)
+		if err != nil {
+			return rv, nextPageToken, nil, err
+		}
+
+		grants := []*v2.Grant{grant.NewGrant(
+			resource,
+			assignedEntitlement,
+			rID,
+		)}
+		if user.Completed.Bool {
+			grants = append(grants, grant.NewGrant(
+				resource,
+				completedEntitlement,
+				rID,
+			))
+		} else {
+			grants = append(grants, grant.NewGrant(
+				resource,
+				inProgressEntitlement,
+				rID,
+			))
+		}
+
+		rv = append(rv, grants...)
+	}
+
+	return rv, nextPageToken, rateLimitAnnotations(rateLimit), nil
+}
+
+// Grant enrolls the principal in the learning path. Only the assigned entitlement can be provisioned.
+func (o *learningPathBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	if principal.Id.ResourceType != userResourceType.Id {
+		l.Warn(
+			"litmos-connector: only users can be assigned to a learning path",
+			zap.String("principal_type", principal.Id.ResourceType),
+			zap.String("principal_id", principal.Id.Resource),
+		)
+		return nil, fmt.Errorf("litmos-connector: only users can be assigned to a learning path")
+	}
+
+	if slug := entitlementSlug(entitlement); slug != assignedEntitlement {
+		return nil, fmt.Errorf("litmos-connector: entitlement %s cannot be provisioned", slug)
+	}
+
+	err := o.client.AssignLearningPathToUser(ctx, principal.Id.Resource, entitlement.Resource.Id.Resource)
+	if err != nil {
+		return nil, fmt.Errorf("litmos-connector: failed to assign learning path: %w", err)
+	}
+
+	return nil, nil
+}
+
+// Revoke unenrolls the principal from the learning path.
+func (o *learningPathBuilder) Revoke(ctx context.Context, g *v2.Grant) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	entitlement := g.Entitlement
+	principal := g.Principal
+
+	if principal.Id.ResourceType != userResourceType.Id {
+		l.Warn(
+			"litmos-connector: only users can be unassigned from a learning path",
+			zap.String("principal_type", principal.Id.ResourceType),
+			zap.String("principal_id", principal.Id.Resource),
+		)
+		return nil, fmt.Errorf("litmos-connector: only users can be unassigned from a learning path")
+	}
+
+	if slug := entitlementSlug(entitlement); slug != assignedEntitlement {
+		return nil, fmt.Errorf("litmos-connector: entitlement %s cannot be provisioned", slug)
+	}
+
+	err := o.client.RemoveLearningPathFromUser(ctx, principal.Id.Resource, entitlement.Resource.Id.Resource)
+	if err != nil {
+		if isNotFound(err) {
+			return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+		}
+		return nil, fmt.Errorf("litmos-connector: failed to unassign learning path: %w", err)
+	}
+
+	return nil, nil
+}
+
+func newLearningPathBuilder(client litmos.Client, userFilter *userFilter) *learningPathBuilder {
+	return &learningPathBuilder{
+		client:     client,
+		userFilter: userFilter,
+	}
+}
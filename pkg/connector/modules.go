@@ -2,14 +2,20 @@ package connector
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/conductorone/baton-litmos/pkg/litmos"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
 	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
 )
 
+const passedEntitlement = "passed"
+const failedEntitlement = "failed"
+
 type moduleBuilder struct {
 	client litmos.Client
 }
@@ -37,7 +43,7 @@ func (o *moduleBuilder) List(ctx context.Context, parentResourceID *v2.ResourceI
 	if parentResourceID == nil {
 		return nil, "", nil, nil
 	}
-	modules, nextPageToken, err := o.client.ListModules(ctx, pToken, parentResourceID.Resource)
+	modules, nextPageToken, rateLimit, err := o.client.ListModules(ctx, pToken, parentResourceID.Resource)
 	if err != nil {
 		return nil, nextPageToken, nil, err
 	}
@@ -50,17 +56,90 @@ func (o *moduleBuilder) List(ctx context.Context, parentResourceID *v2.ResourceI
 		}
 		resources = append(resources, resource)
 	}
-	return resources, nextPageToken, nil, nil
+	return resources, nextPageToken, rateLimitAnnotations(rateLimit), nil
 }
 
 func (o *moduleBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
-	return nil, "", nil, nil
+	var rv []*v2.Entitlement
+	completedOptions := []entitlement.EntitlementOption{
+		entitlement.WithGrantableTo(userResourceType),
+		entitlement.WithDisplayName(fmt.Sprintf("Module %s %s", resource.DisplayName, completedEntitlement)),
+		entitlement.WithDescription(fmt.Sprintf("Completed module %s in Litmos", resource.DisplayName)),
+	}
+	passedOptions := []entitlement.EntitlementOption{
+		entitlement.WithGrantableTo(userResourceType),
+		entitlement.WithDisplayName(fmt.Sprintf("Module %s %s", resource.DisplayName, passedEntitlement)),
+		entitlement.WithDescription(fmt.Sprintf("Passed module %s in Litmos", resource.DisplayName)),
+	}
+	failedOptions := []entitlement.EntitlementOption{
+		entitlement.WithGrantableTo(userResourceType),
+		entitlement.WithDisplayName(fmt.Sprintf("Module %s %s", resource.DisplayName, failedEntitlement)),
+		entitlement.WithDescription(fmt.Sprintf("Failed module %s in Litmos", resource.DisplayName)),
+	}
+
+	entitlements := []*v2.Entitlement{
+		entitlement.NewAssignmentEntitlement(
+			resource,
+			completedEntitlement,
+			completedOptions...,
+		),
+		entitlement.NewAssignmentEntitlement(
+			resource,
+			passedEntitlement,
+			passedOptions...,
+		),
+		entitlement.NewAssignmentEntitlement(
+			resource,
+			failedEntitlement,
+			failedOptions...,
+		),
+	}
+	rv = append(rv, entitlements...)
+	return rv, "", nil, nil
 }
 
+// Grants returns no grants. Module results are fetched once per enrolled user of the course and emitted by
+// courseBuilder.Grants, see moduleGrants.
 func (o *moduleBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
 	return nil, "", nil, nil
 }
 
+// moduleGrants returns the grants of the user on the modules of the course. A single request returns the results on
+// every module, so the course looks them up for each enrolled user instead of each module doing so.
+func moduleGrants(ctx context.Context, client litmos.Client, course *v2.Resource, userID *v2.ResourceId) ([]*v2.Grant, error) {
+	results, err := client.ListUserModuleResults(ctx, userID.Resource, course.Id.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	var rv []*v2.Grant
+	for _, result := range results {
+		mID, err := rs.NewResourceID(moduleResourceType, result.Id)
+		if err != nil {
+			return nil, err
+		}
+		module := &v2.Resource{Id: mID, ParentResourceId: course.Id}
+		metadata := grant.WithGrantMetadata(map[string]interface{}{
+			"score":          result.Score.Value(),
+			"pass_mark":      result.PassMark.Value(),
+			"attempts":       result.Attempt.Value(),
+			"start_date":     result.StartDate.Value(),
+			"date_completed": result.DateCompleted.Value(),
+		})
+
+		if result.Completed.Bool {
+			rv = append(rv, grant.NewGrant(module, completedEntitlement, userID, metadata))
+		}
+		switch {
+		case result.Passed.Bool:
+			rv = append(rv, grant.NewGrant(module, passedEntitlement, userID, metadata))
+		case result.Attempt.Int64 > 0 && result.PassMark.Float64 > 0:
+			rv = append(rv, grant.NewGrant(module, failedEntitlement, userID, metadata))
+		}
+	}
+	return rv, nil
+}
+
 func newModuleBuilder(client litmos.Client) *moduleBuilder {
 	return &moduleBuilder{
 		client: client,
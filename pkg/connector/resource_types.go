@@ -2,7 +2,6 @@ package connector
 
 import (
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
-	"github.com/conductorone/baton-sdk/pkg/annotations"
 )
 
 // The user resource type is for all user objects from the database.
@@ -10,7 +9,6 @@ var userResourceType = &v2.ResourceType{
 	Id:          "user",
 	DisplayName: "User",
 	Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_USER},
-	Annotations: annotations.New(&v2.SkipEntitlementsAndGrants{}),
 }
 
 var teamResourceType = &v2.ResourceType{
@@ -19,13 +17,23 @@ var teamResourceType = &v2.ResourceType{
 	Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_GROUP},
 }
 
+var roleResourceType = &v2.ResourceType{
+	Id:          "role",
+	DisplayName: "Role",
+	Traits:      []v2.ResourceType_Trait{v2.ResourceType_TRAIT_ROLE},
+}
+
 var courseResourceType = &v2.ResourceType{
 	Id:          "course",
 	DisplayName: "Course",
 }
 
+var learningPathResourceType = &v2.ResourceType{
+	Id:          "learning_path",
+	DisplayName: "Learning Path",
+}
+
 var moduleResourceType = &v2.ResourceType{
 	Id:          "module",
 	DisplayName: "Module",
-	Annotations: annotations.New(&v2.SkipEntitlementsAndGrants{}),
 }
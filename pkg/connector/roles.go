@@ -0,0 +1,235 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conductorone/baton-litmos/pkg/litmos"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+// accessLevel is a Litmos user access level, synced as a role.
+type accessLevel struct {
+	Id          string
+	Name        string
+	Description string
+}
+
+var accessLevels = []accessLevel{
+	{
+		Id:          "learner",
+		Name:        litmos.AccessLevelLearner,
+		Description: "Can take the courses assigned to them",
+	},
+	{
+		Id:          "team_leader",
+		Name:        litmos.AccessLevelTeamLeader,
+		Description: "Can manage and report on the learners of the teams they lead",
+	},
+	{
+		Id:          "administrator",
+		Name:        litmos.AccessLevelAdministrator,
+		Description: "Can manage all users, teams and courses of the Litmos account",
+	},
+	{
+		Id:          "account_owner",
+		Name:        litmos.AccessLevelAccountOwner,
+		Description: "Owns the Litmos account, including billing",
+	},
+}
+
+func accessLevelByID(id string) (*accessLevel, error) {
+	for _, level := range accessLevels {
+		if level.Id == id {
+			return &level, nil
+		}
+	}
+	return nil, fmt.Errorf("litmos-connector: unknown access level %s", id)
+}
+
+func accessLevelByName(name string) (*accessLevel, bool) {
+	for _, level := range accessLevels {
+		if level.Name == name {
+			return &level, true
+		}
+	}
+	return nil, false
+}
+
+type roleBuilder struct {
+	client litmos.Client
+}
+
+func (o *roleBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
+	return roleResourceType
+}
+
+func roleResource(ctx context.Context, level *accessLevel, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"access_level": level.Name,
+	}
+
+	roleTraitOptions := []rs.RoleTraitOption{
+		rs.WithRoleProfile(profile),
+	}
+
+	resource, err := rs.NewRoleResource(
+		level.Name,
+		roleResourceType,
+		level.Id,
+		roleTraitOptions,
+		rs.WithParentResourceID(parentResourceID),
+		rs.WithDescription(level.Description),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// List returns one role for each Litmos access level.
+func (o *roleBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	resources := make([]*v2.Resource, 0, len(accessLevels))
+	for _, level := range accessLevels {
+		resource, err := roleResource(ctx, &level, parentResourceID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		resources = append(resources, resource)
+	}
+	return resources, "", nil, nil
+}
+
+func (o *roleBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	assignedOptions := []entitlement.EntitlementOption{
+		entitlement.WithGrantableTo(userResourceType),
+		entitlement.WithDisplayName(fmt.Sprintf("Role %s %s", resource.DisplayName, assignedEntitlement)),
+		entitlement.WithDescription(fmt.Sprintf("Has the %s access level in Litmos", resource.DisplayName)),
+	}
+
+	return []*v2.Entitlement{
+		entitlement.NewAssignmentEntitlement(
+			resource,
+			assignedEntitlement,
+			assignedOptions...,
+		),
+	}, "", nil, nil
+}
+
+// Grants returns no grants. Every user holds exactly one access level, so the role grants are emitted by
+// userBuilder.Grants from the synced user instead of listing all users once per role.
+func (o *roleBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+// roleGrant returns the grant of the role matching the access level to the user, or nil for an unknown level.
+func roleGrant(user *v2.Resource, accessLevelName string) (*v2.Grant, error) {
+	level, ok := accessLevelByName(accessLevelName)
+	if !ok {
+		return nil, nil
+	}
+	rID, err := rs.NewResourceID(roleResourceType, level.Id)
+	if err != nil {
+		return nil, err
+	}
+	return grant.NewGrant(&v2.Resource{Id: rID}, assignedEntitlement, user.Id), nil
+}
+
+// Grant changes the access level of the principal. The Account Owner level is managed by Litmos, so it can neither be
+// granted nor taken away.
+func (o *roleBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	if principal.Id.ResourceType != userResourceType.Id {
+		l.Warn(
+			"litmos-connector: only users can be granted an access level",
+			zap.String("principal_type", principal.Id.ResourceType),
+			zap.String("principal_id", principal.Id.Resource),
+		)
+		return nil, fmt.Errorf("litmos-connector: only users can be granted an access level")
+	}
+
+	level, err := accessLevelByID(entitlement.Resource.Id.Resource)
+	if err != nil {
+		return nil, err
+	}
+	if level.Name == litmos.AccessLevelAccountOwner {
+		return nil, fmt.Errorf("litmos-connector: the %s access level cannot be granted", level.Name)
+	}
+
+	user, err := o.client.GetUser(ctx, principal.Id.Resource)
+	if err != nil {
+		return nil, fmt.Errorf("litmos-connector: failed to get user: %w", err)
+	}
+	switch user.AccessLevel {
+	case level.Name:
+		return annotations.New(&v2.GrantAlreadyExists{}), nil
+	case litmos.AccessLevelAccountOwner:
+		return nil, fmt.Errorf("litmos-connector: the access level of the %s cannot be changed", litmos.AccessLevelAccountOwner)
+	}
+
+	user.AccessLevel = level.Name
+	err = o.client.UpdateUser(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("litmos-connector: failed to set access level: %w", err)
+	}
+
+	return nil, nil
+}
+
+// Revoke demotes the principal to Learner. Every Litmos user has exactly one access level, so the Learner level itself
+// cannot be revoked, and Account Owner is refused.
+func (o *roleBuilder) Revoke(ctx context.Context, g *v2.Grant) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	entitlement := g.Entitlement
+	principal := g.Principal
+
+	if principal.Id.ResourceType != userResourceType.Id {
+		l.Warn(
+			"litmos-connector: only users can be revoked an access level",
+			zap.String("principal_type", principal.Id.ResourceType),
+			zap.String("principal_id", principal.Id.Resource),
+		)
+		return nil, fmt.Errorf("litmos-connector: only users can be revoked an access level")
+	}
+
+	level, err := accessLevelByID(entitlement.Resource.Id.Resource)
+	if err != nil {
+		return nil, err
+	}
+	switch level.Name {
+	case litmos.AccessLevelAccountOwner, litmos.AccessLevelLearner:
+		return nil, fmt.Errorf("litmos-connector: the %s access level cannot be revoked", level.Name)
+	}
+
+	user, err := o.client.GetUser(ctx, principal.Id.Resource)
+	if err != nil {
+		return nil, fmt.Errorf("litmos-connector: failed to get user: %w", err)
+	}
+	if user.AccessLevel != level.Name {
+		return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+	}
+
+	user.AccessLevel = litmos.AccessLevelLearner
+	err = o.client.UpdateUser(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("litmos-connector: failed to set access level: %w", err)
+	}
+
+	return nil, nil
+}
+
+func newRoleBuilder(client litmos.Client) *roleBuilder {
+	return &roleBuilder{
+		client: client,
+	}
+}
@@ -0,0 +1,146 @@
+package connector
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/conductorone/baton-litmos/pkg/litmos"
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+// fakeUserServer serves a single Litmos user and applies the updates it receives.
+type fakeUserServer struct {
+	mu   sync.Mutex
+	user litmos.User
+	puts int
+}
+
+func (f *fakeUserServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		b, err := xml.Marshal(&f.user)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeXML(w, string(b))
+	case http.MethodPut:
+		b, err := io.ReadAll(r.Body)
+		if err == nil {
+			err = xml.Unmarshal(b, &f.user)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.puts++
+	}
+}
+
+func roleEntitlement(t *testing.T, levelId string) *v2.Entitlement {
+	t.Helper()
+	level, err := accessLevelByID(levelId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	role, err := roleResource(context.Background(), level, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entitlement.NewAssignmentEntitlement(role, assignedEntitlement)
+}
+
+func TestRoleRevokeAfterGrant(t *testing.T) {
+	srv := &fakeUserServer{user: litmos.User{Id: "u1", UserName: "ada", AccessLevel: litmos.AccessLevelLearner}}
+	o := newRoleBuilder(newTestClient(t, srv))
+	ctx := context.Background()
+
+	principal, err := rs.NewUserResource("ada", userResourceType, "u1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin := roleEntitlement(t, "administrator")
+
+	annos, err := o.Grant(ctx, principal, admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if annos.Contains(&v2.GrantAlreadyExists{}) {
+		t.Fatal("Grant reported the administrator role as already granted")
+	}
+	if srv.user.AccessLevel != litmos.AccessLevelAdministrator {
+		t.Fatalf("access level after grant is %q", srv.user.AccessLevel)
+	}
+
+	// The user record read by Grant must not be reused, or Revoke would see the user as a Learner.
+	annos, err = o.Revoke(ctx, &v2.Grant{Entitlement: admin, Principal: principal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if annos.Contains(&v2.GrantAlreadyRevoked{}) {
+		t.Fatal("Revoke reported the administrator role as already revoked")
+	}
+	if srv.user.AccessLevel != litmos.AccessLevelLearner {
+		t.Fatalf("access level after revoke is %q", srv.user.AccessLevel)
+	}
+
+	// Granting again must not be mistaken for an existing grant either.
+	annos, err = o.Grant(ctx, principal, admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if annos.Contains(&v2.GrantAlreadyExists{}) {
+		t.Fatal("second Grant reported the administrator role as already granted")
+	}
+	if srv.puts != 3 {
+		t.Fatalf("expected 3 updates, got %d", srv.puts)
+	}
+}
+
+func TestUserGrantsIncludeRole(t *testing.T) {
+	ctx := context.Background()
+	o := newUserBuilder(litmos.Client{}, nil, userSettings{}, nil)
+
+	for _, tc := range []struct {
+		accessLevel string
+		wantRole    string
+	}{
+		{litmos.AccessLevelLearner, "learner"},
+		{litmos.AccessLevelTeamLeader, "team_leader"},
+		{litmos.AccessLevelAccountOwner, "account_owner"},
+		{"Unknown", ""},
+	} {
+		user := &litmos.User{Id: "u1", UserName: "ada", AccessLevel: tc.accessLevel, Active: litmos.NewBool(true)}
+		resource, err := userResource(ctx, user, nil, userSettings{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		grants, _, _, err := o.Grants(ctx, resource, &pagination.Token{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var roles []string
+		for _, g := range grants {
+			if g.Entitlement.Resource.Id.ResourceType == roleResourceType.Id {
+				roles = append(roles, g.Entitlement.Resource.Id.Resource)
+			}
+		}
+		switch {
+		case tc.wantRole == "" && len(roles) != 0:
+			t.Errorf("%s: unexpected role grants %v", tc.accessLevel, roles)
+		case tc.wantRole != "" && (len(roles) != 1 || roles[0] != tc.wantRole):
+			t.Errorf("%s: role grants %v, want %s", tc.accessLevel, roles, tc.wantRole)
+		}
+	}
+}
@@ -11,12 +11,20 @@ import (
 	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
 	"github.com/conductorone/baton-sdk/pkg/types/grant"
 	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
 )
 
 const memberEntitlement = "member"
+const leaderEntitlement = "leader"
+const adminEntitlement = "admin"
 
 type teamBuilder struct {
-	client litmos.Client
+	client       litmos.Client
+	limitCourses mapset.Set[string]
+	userSettings userSettings
+	userFilter   *userFilter
 }
 
 func (o *teamBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
@@ -41,6 +49,7 @@ func teamResource(ctx context.Context, team *litmos.Team, parentResourceID *v2.R
 		team.Id,
 		groupTraitOptions,
 		rs.WithParentResourceID(parentResourceID),
+		rs.WithAnnotation(&v2.ChildResourceType{ResourceTypeId: teamResourceType.Id}),
 	)
 
 	if err != nil {
@@ -50,21 +59,34 @@ func teamResource(ctx context.Context, team *litmos.Team, parentResourceID *v2.R
 	return resource, nil
 }
 
+// List returns the root teams, or the direct sub-teams of the parent team. Sub-teams are reached through the
+// ChildResourceType annotation on their parent, so the tree is walked one level at a time.
 func (o *teamBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
-	teams, nextPageToken, err := o.client.ListTeams(ctx, pToken)
+	var teams []litmos.Team
+	var nextPageToken string
+	var rateLimit *v2.RateLimitDescription
+	var err error
+	if parentResourceID == nil {
+		teams, nextPageToken, rateLimit, err = o.client.ListTeams(ctx, pToken)
+	} else {
+		teams, nextPageToken, rateLimit, err = o.client.ListSubTeams(ctx, pToken, parentResourceID.Resource)
+	}
 	if err != nil {
 		return nil, nextPageToken, nil, err
 	}
 
 	resources := make([]*v2.Resource, 0, len(teams))
 	for _, team := range teams {
+		if parentResourceID == nil && team.ParentTeamId != "" {
+			continue
+		}
 		resource, err := teamResource(ctx, &team, parentResourceID)
 		if err != nil {
 			return nil, "", nil, err
 		}
 		resources = append(resources, resource)
 	}
-	return resources, nextPageToken, nil, nil
+	return resources, nextPageToken, rateLimitAnnotations(rateLimit), nil
 }
 
 func (o *teamBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
@@ -74,24 +96,90 @@ func (o *teamBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *
 		entitlement.WithDisplayName(fmt.Sprintf("Team %s %s", resource.DisplayName, memberEntitlement)),
 		entitlement.WithDescription(fmt.Sprintf("Member of team %s in Litmos", resource.DisplayName)),
 	}
+	leaderOptions := []entitlement.EntitlementOption{
+		entitlement.WithGrantableTo(userResourceType),
+		entitlement.WithDisplayName(fmt.Sprintf("Team %s %s", resource.DisplayName, leaderEntitlement)),
+		entitlement.WithDescription(fmt.Sprintf("Leader of team %s in Litmos", resource.DisplayName)),
+	}
+	adminOptions := []entitlement.EntitlementOption{
+		entitlement.WithGrantableTo(userResourceType),
+		entitlement.WithDisplayName(fmt.Sprintf("Team %s %s", resource.DisplayName, adminEntitlement)),
+		entitlement.WithDescription(fmt.Sprintf("Admin of team %s in Litmos", resource.DisplayName)),
+	}
 
-	rv = append(rv, entitlement.NewAssignmentEntitlement(
-		resource,
-		memberEntitlement,
-		assignmentOptions...,
-	))
+	rv = append(rv,
+		entitlement.NewAssignmentEntitlement(
+			resource,
+			memberEntitlement,
+			assignmentOptions...,
+		),
+		entitlement.NewPermissionEntitlement(
+			resource,
+			leaderEntitlement,
+			leaderOptions...,
+		),
+		entitlement.NewPermissionEntitlement(
+			resource,
+			adminEntitlement,
+			adminOptions...,
+		),
+	)
 	return rv, "", nil, nil
 }
 
+// Grants pages through the members, leaders and admins of the team in turn, followed by its sub-teams and the courses
+// assigned to it. The entitlement being listed is tracked in the pagination bag.
 func (o *teamBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
-	users, nextPageToken, err := o.client.ListTeamUsers(ctx, pToken, resource.Id.Resource)
+	bag := &pagination.Bag{}
+	err := bag.Unmarshal(pToken.Token)
 	if err != nil {
-		return nil, nextPageToken, nil, err
+		return nil, "", nil, err
+	}
+	if bag.Current() == nil {
+		bag.Push(pagination.PageState{ResourceTypeID: courseResourceType.Id})
+		bag.Push(pagination.PageState{ResourceTypeID: teamResourceType.Id})
+		bag.Push(pagination.PageState{ResourceTypeID: adminEntitlement})
+		bag.Push(pagination.PageState{ResourceTypeID: leaderEntitlement})
+		bag.Push(pagination.PageState{ResourceTypeID: memberEntitlement})
+	}
+
+	entitlementName := bag.ResourceTypeID()
+	page := &pagination.Token{Size: pToken.Size, Token: bag.PageToken()}
+
+	switch entitlementName {
+	case teamResourceType.Id:
+		return o.subTeamGrants(ctx, resource, page, bag)
+	case courseResourceType.Id:
+		return o.courseGrants(ctx, resource, page, bag)
+	}
+
+	var users []litmos.User
+	var nextPageToken string
+	var rateLimit *v2.RateLimitDescription
+	switch entitlementName {
+	case memberEntitlement:
+		users, nextPageToken, rateLimit, err = o.client.ListTeamUsers(ctx, page, resource.Id.Resource)
+	case leaderEntitlement:
+		users, nextPageToken, rateLimit, err = o.client.ListTeamLeaders(ctx, page, resource.Id.Resource)
+	case adminEntitlement:
+		users, nextPageToken, rateLimit, err = o.client.ListTeamAdmins(ctx, page, resource.Id.Resource)
+	default:
+		return nil, "", nil, fmt.Errorf("litmos-connector: unexpected team entitlement %s in page token", entitlementName)
+	}
+	if err != nil {
+		return nil, "", nil, err
 	}
 
 	rv := make([]*v2.Grant, 0, len(users))
 	for _, user := range users {
-		u, err := userResource(ctx, &user, nil)
+		allowed, err := o.userFilter.AllowsID(ctx, user.Id)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		if !allowed {
+			continue
+		}
+		u, err := userResource(ctx, &user, nil, o.userSettings)
 		if err != nil {
 			return nil, "", nil, err
 		}
@@ -99,17 +187,197 @@ func (o *teamBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken
 			rv,
 			grant.NewGrant(
 				resource,
-				memberEntitlement,
+				entitlementName,
 				u.Id,
 			),
 		)
 	}
 
-	return rv, nextPageToken, nil, nil
+	err = bag.Next(nextPageToken)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	nextToken, err := bag.Marshal()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return rv, nextToken, rateLimitAnnotations(rateLimit), nil
+}
+
+// subTeamGrants grants the member entitlement of the team to each of its sub-teams. The grants are expandable, so
+// members of a sub-team are also members of every team above it. Litmos does not move teams through its membership
+// endpoints, so these grants are only synced and the entitlement stays grantable to users alone.
+func (o *teamBuilder) subTeamGrants(ctx context.Context, resource *v2.Resource, page *pagination.Token, bag *pagination.Bag) ([]*v2.Grant, string, annotations.Annotations, error) {
+	teams, nextPageToken, rateLimit, err := o.client.ListSubTeams(ctx, page, resource.Id.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	rv := make([]*v2.Grant, 0, len(teams))
+	for _, team := range teams {
+		t, err := teamResource(ctx, &team, resource.Id)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		rv = append(
+			rv,
+			grant.NewGrant(
+				resource,
+				memberEntitlement,
+				t.Id,
+				grant.WithAnnotation(&v2.GrantExpandable{
+					EntitlementIds: []string{entitlement.NewEntitlementID(t, memberEntitlement)},
+				}),
+			),
+		)
+	}
+
+	err = bag.Next(nextPageToken)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	nextToken, err := bag.Marshal()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return rv, nextToken, rateLimitAnnotations(rateLimit), nil
+}
+
+// courseGrants grants the assigned entitlement of each course assigned to the team to the team. The grants are
+// expandable, so members of the team are shown as assigned through the team and not only through their enrollment.
+// Litmos assigns a team course to the direct members of the team only, so the expansion is shallow and skips the
+// members that come from sub-teams, as well as the sub-teams themselves.
+func (o *teamBuilder) courseGrants(ctx context.Context, resource *v2.Resource, page *pagination.Token, bag *pagination.Bag) ([]*v2.Grant, string, annotations.Annotations, error) {
+	courses, nextPageToken, rateLimit, err := o.client.ListTeamCourses(ctx, page, resource.Id.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	rv := make([]*v2.Grant, 0, len(courses))
+	for _, course := range courses {
+		if o.limitCourses != nil && !o.limitCourses.Contains(course.Id) {
+			continue
+		}
+		courseID, err := rs.NewResourceID(courseResourceType, course.Id)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		rv = append(
+			rv,
+			grant.NewGrant(
+				&v2.Resource{Id: courseID},
+				assignedEntitlement,
+				resource.Id,
+				grant.WithAnnotation(&v2.GrantExpandable{
+					EntitlementIds:  []string{entitlement.NewEntitlementID(resource, memberEntitlement)},
+					Shallow:         true,
+					ResourceTypeIds: []string{userResourceType.Id},
+				}),
+			),
+		)
+	}
+
+	err = bag.Next(nextPageToken)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	nextToken, err := bag.Marshal()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return rv, nextToken, rateLimitAnnotations(rateLimit), nil
+}
+
+// Grant adds the principal to the team, or promotes them to leader or admin. Litmos only promotes existing members, so
+// the principal is added to the team before being promoted.
+func (o *teamBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	if principal.Id.ResourceType != userResourceType.Id {
+		l.Warn(
+			"litmos-connector: only users can be granted team entitlements",
+			zap.String("principal_type", principal.Id.ResourceType),
+			zap.String("principal_id", principal.Id.Resource),
+		)
+		return nil, fmt.Errorf("litmos-connector: only users can be granted team entitlements")
+	}
+
+	teamId := entitlement.Resource.Id.Resource
+	userId := principal.Id.Resource
+
+	var err error
+	switch slug := entitlementSlug(entitlement); slug {
+	case memberEntitlement:
+		err = o.client.AddUserToTeam(ctx, teamId, userId)
+	case leaderEntitlement:
+		err = o.client.AddUserToTeam(ctx, teamId, userId)
+		if err == nil {
+			err = o.client.PromoteTeamLeader(ctx, teamId, userId)
+		}
+	case adminEntitlement:
+		err = o.client.AddUserToTeam(ctx, teamId, userId)
+		if err == nil {
+			err = o.client.PromoteTeamAdmin(ctx, teamId, userId)
+		}
+	default:
+		return nil, fmt.Errorf("litmos-connector: entitlement %s cannot be provisioned", slug)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("litmos-connector: failed to grant team entitlement: %w", err)
+	}
+
+	return nil, nil
+}
+
+// Revoke removes the principal from the team, or demotes them from leader or admin. Revoking an entitlement the user
+// no longer holds in Litmos is not an error.
+func (o *teamBuilder) Revoke(ctx context.Context, g *v2.Grant) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	entitlement := g.Entitlement
+	principal := g.Principal
+
+	if principal.Id.ResourceType != userResourceType.Id {
+		l.Warn(
+			"litmos-connector: only users can be revoked team entitlements",
+			zap.String("principal_type", principal.Id.ResourceType),
+			zap.String("principal_id", principal.Id.Resource),
+		)
+		return nil, fmt.Errorf("litmos-connector: only users can be revoked team entitlements")
+	}
+
+	teamId := entitlement.Resource.Id.Resource
+	userId := principal.Id.Resource
+
+	var err error
+	switch slug := entitlementSlug(entitlement); slug {
+	case memberEntitlement:
+		err = o.client.RemoveUserFromTeam(ctx, teamId, userId)
+	case leaderEntitlement:
+		err = o.client.DemoteTeamLeader(ctx, teamId, userId)
+	case adminEntitlement:
+		err = o.client.DemoteTeamAdmin(ctx, teamId, userId)
+	default:
+		return nil, fmt.Errorf("litmos-connector: entitlement %s cannot be provisioned", slug)
+	}
+	if err != nil {
+		if isNotFound(err) {
+			return annotations.New(&v2.GrantAlreadyRevoked{}), nil
+		}
+		return nil, fmt.Errorf("litmos-connector: failed to revoke team entitlement: %w", err)
+	}
+
+	return nil, nil
 }
 
-func newTeamBuilder(client litmos.Client) *teamBuilder {
+func newTeamBuilder(client litmos.Client, limitCourses mapset.Set[string], userSettings userSettings, userFilter *userFilter) *teamBuilder {
 	return &teamBuilder{
-		client: client,
+		client:       client,
+		limitCourses: limitCourses,
+		userSettings: userSettings,
+		userFilter:   userFilter,
 	}
 }
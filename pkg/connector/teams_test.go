@@ -0,0 +1,83 @@
+package connector
+
+import (
+	"context"
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+func TestTeamCourseGrantsExpandToDirectMembers(t *testing.T) {
+	api := newFakeAPI(map[string]string{
+		"/v1.svc/teams/t1/courses": `<Courses><Course><Id>c1</Id><Name>Course</Name></Course></Courses>`,
+	})
+	o := newTeamBuilder(newTestClient(t, api), nil, userSettings{}, nil)
+
+	team, err := rs.NewResource("Team", teamResourceType, "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bag := &pagination.Bag{}
+	bag.Push(pagination.PageState{ResourceTypeID: courseResourceType.Id})
+	grants, _, _, err := o.courseGrants(context.Background(), team, &pagination.Token{}, bag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(grants) != 1 {
+		t.Fatalf("got %d grants, want 1", len(grants))
+	}
+
+	expandable := &v2.GrantExpandable{}
+	annos := annotations.Annotations(grants[0].Annotations)
+	ok, err := annos.Pick(expandable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("course grant is not expandable")
+	}
+	if !expandable.Shallow {
+		t.Error("course grant expands the members of sub-teams")
+	}
+	if len(expandable.ResourceTypeIds) != 1 || expandable.ResourceTypeIds[0] != userResourceType.Id {
+		t.Errorf("course grant expands to %v, want users only", expandable.ResourceTypeIds)
+	}
+	if want := "team:t1:member"; len(expandable.EntitlementIds) != 1 || expandable.EntitlementIds[0] != want {
+		t.Errorf("course grant expands %v, want %s", expandable.EntitlementIds, want)
+	}
+}
+
+func TestTeamGrantPromotesAfterAddingMember(t *testing.T) {
+	for _, slug := range []string{leaderEntitlement, adminEntitlement} {
+		t.Run(slug, func(t *testing.T) {
+			api := newFakeAPI(map[string]string{
+				"/v1.svc/teams/t1/users":            "",
+				"/v1.svc/teams/t1/" + slug + "s/u1": "",
+			})
+			o := newTeamBuilder(newTestClient(t, api), nil, userSettings{}, nil)
+
+			team, err := rs.NewResource("Team", teamResourceType, "t1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			principal, err := rs.NewUserResource("ada", userResourceType, "u1", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = o.Grant(context.Background(), principal, entitlement.NewAssignmentEntitlement(team, slug))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hits := api.Hits("/v1.svc/teams/t1/users"); hits != 1 {
+				t.Errorf("user was added to the team %d times, want 1", hits)
+			}
+			if hits := api.Hits("/v1.svc/teams/t1/" + slug + "s/u1"); hits != 1 {
+				t.Errorf("user was promoted %d times, want 1", hits)
+			}
+		})
+	}
+}
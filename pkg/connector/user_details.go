@@ -0,0 +1,87 @@
+package connector
+
+import (
+	"context"
+	"sync"
+
+	"github.com/conductorone/baton-litmos/pkg/litmos"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+// userDetails fetches and caches the full Litmos record of users. The list endpoints only return a summary, and the
+// detail endpoint costs one request per user, so at most limit users are looked up per sync.
+type userDetails struct {
+	client litmos.Client
+	// limit caps the number of detail requests per sync, a negative value does not limit them.
+	limit int
+	// customFields logs each user past the limit, since their mapped custom fields are missing from the profile.
+	customFields bool
+
+	mu      sync.Mutex
+	users   map[string]*litmos.User
+	fetched int
+	warned  bool
+}
+
+func newUserDetails(client litmos.Client, limit int, customFields bool) *userDetails {
+	return &userDetails{
+		client:       client,
+		limit:        limit,
+		customFields: customFields,
+		users:        make(map[string]*litmos.User),
+	}
+}
+
+// Reset drops the cached users, so the next sync sees fresh details.
+func (d *userDetails) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.users = make(map[string]*litmos.User)
+	d.fetched = 0
+	d.warned = false
+}
+
+// Get returns the full record of the user. The summary is returned unchanged once the limit is reached, or when
+// Litmos no longer knows the user.
+func (d *userDetails) Get(ctx context.Context, summary *litmos.User) (*litmos.User, error) {
+	d.mu.Lock()
+	if user, ok := d.users[summary.Id]; ok {
+		d.mu.Unlock()
+		return user, nil
+	}
+	if d.limit >= 0 && d.fetched >= d.limit {
+		l := ctxzap.Extract(ctx)
+		if !d.warned {
+			d.warned = true
+			l.Warn(
+				"litmos-connector: user detail limit reached, remaining users are synced without details",
+				zap.Int("limit", d.limit),
+			)
+		}
+		if d.customFields {
+			l.Warn(
+				"litmos-connector: user synced without custom fields, the user detail limit is reached",
+				zap.String("user_id", summary.Id),
+				zap.Int("limit", d.limit),
+			)
+		}
+		d.mu.Unlock()
+		return summary, nil
+	}
+	d.fetched++
+	d.mu.Unlock()
+
+	user, err := d.client.GetUser(ctx, summary.Id)
+	if err != nil {
+		if isNotFound(err) {
+			return summary, nil
+		}
+		return nil, err
+	}
+
+	d.mu.Lock()
+	d.users[summary.Id] = user
+	d.mu.Unlock()
+	return user, nil
+}
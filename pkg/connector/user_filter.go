@@ -0,0 +1,100 @@
+package connector
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/conductorone/baton-litmos/pkg/litmos"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	mapset "github.com/deckarep/golang-set/v2"
+)
+
+// userFilter limits the users that are synced. Grants are only emitted for users that pass the filter, so that every
+// principal of a grant is also a synced user. A nil filter allows every user.
+type userFilter struct {
+	client          litmos.Client
+	excludeInactive bool
+	// accessLevels and brands allow any value when empty. Values are compared case-insensitively.
+	accessLevels []string
+	brands       []string
+
+	mu sync.Mutex
+	// allowed holds the IDs of the users that pass the filter, nil until it is loaded.
+	allowed mapset.Set[string]
+}
+
+// Allows reports whether the user passes the filter.
+func (f *userFilter) Allows(user *litmos.User) bool {
+	if f == nil {
+		return true
+	}
+	if f.excludeInactive && user.Active.Valid && !user.Active.Bool {
+		return false
+	}
+	if len(f.accessLevels) > 0 && !containsFold(f.accessLevels, user.AccessLevel) {
+		return false
+	}
+	if len(f.brands) > 0 && !containsFold(f.brands, user.Brand) {
+		return false
+	}
+	return true
+}
+
+// containsFold reports whether value is one of values, ignoring case and surrounding spaces.
+func containsFold(values []string, value string) bool {
+	value = strings.TrimSpace(value)
+	return slices.ContainsFunc(values, func(v string) bool {
+		return strings.EqualFold(strings.TrimSpace(v), value)
+	})
+}
+
+// AllowsID reports whether the user with the ID passes the filter. Endpoints listing enrollments do not return the
+// fields the filter looks at, so the IDs of all allowed users are loaded on first use.
+func (f *userFilter) AllowsID(ctx context.Context, userId string) (bool, error) {
+	if f == nil {
+		return true, nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.allowed == nil {
+		allowed, err := f.load(ctx)
+		if err != nil {
+			return false, err
+		}
+		f.allowed = allowed
+	}
+	return f.allowed.Contains(userId), nil
+}
+
+// Reset drops the loaded IDs, so the next sync sees users that changed since.
+func (f *userFilter) Reset() {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.allowed = nil
+}
+
+func (f *userFilter) load(ctx context.Context) (mapset.Set[string], error) {
+	allowed := mapset.NewThreadUnsafeSet[string]()
+	page := &pagination.Token{}
+	for {
+		users, nextPageToken, _, err := f.client.ListUsers(ctx, page)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			if f.Allows(&user) {
+				allowed.Add(user.Id)
+			}
+		}
+		if nextPageToken == "" {
+			return allowed, nil
+		}
+		page.Token = nextPageToken
+	}
+}
@@ -0,0 +1,93 @@
+package connector
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/conductorone/baton-litmos/pkg/litmos"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+func TestUserFilterDropsGrantsOfFilteredUsers(t *testing.T) {
+	api := newFakeAPI(map[string]string{
+		"/v1.svc/users": `<Users>
+			<User><Id>u1</Id><UserName>ada</UserName><Active>true</Active><AccessLevel>Learner</AccessLevel><Brand>Acme</Brand></User>
+			<User><Id>u2</Id><UserName>grace</UserName><Active>true</Active><AccessLevel>Administrator</AccessLevel><Brand>Acme</Brand></User>
+			<User><Id>u3</Id><UserName>alan</UserName><Active>true</Active><AccessLevel>Learner</AccessLevel><Brand>Other</Brand></User>
+		</Users>`,
+		"/v1.svc/courses/c1/users":        `<Users><User><Id>u1</Id></User><User><Id>u2</Id></User><User><Id>u3</Id></User></Users>`,
+		"/v1.svc/teams/t1/users":          `<Users><User><Id>u1</Id></User><User><Id>u2</Id></User><User><Id>u3</Id></User></Users>`,
+		"/v1.svc/learningpaths/lp1/users": `<Users><User><Id>u1</Id></User><User><Id>u2</Id></User><User><Id>u3</Id></User></Users>`,
+	})
+	client := newTestClient(t, api)
+	// Configured values match regardless of case.
+	filter := &userFilter{client: client, accessLevels: []string{"learner"}, brands: []string{"ACME"}}
+	ctx := context.Background()
+
+	course, err := rs.NewResource("Course", courseResourceType, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grants, _, _, err := newCourseBuilder(client, nil, false, courseAssignment{}, false, filter).Grants(ctx, course, &pagination.Token{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := grantKeys(grants), []string{"c1:assigned:u1", "c1:in_progress:u1"}; !slices.Equal(got, want) {
+		t.Errorf("course grants %v, want %v", got, want)
+	}
+
+	team, err := rs.NewResource("Team", teamResourceType, "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grants, _, _, err = newTeamBuilder(client, nil, userSettings{}, filter).Grants(ctx, team, &pagination.Token{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := grantKeys(grants), []string{"t1:member:u1"}; !slices.Equal(got, want) {
+		t.Errorf("team grants %v, want %v", got, want)
+	}
+
+	path, err := rs.NewResource("Learning path", learningPathResourceType, "lp1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grants, _, _, err = newLearningPathBuilder(client, filter).Grants(ctx, path, &pagination.Token{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := grantKeys(grants), []string{"lp1:assigned:u1", "lp1:in_progress:u1"}; !slices.Equal(got, want) {
+		t.Errorf("learning path grants %v, want %v", got, want)
+	}
+
+	if hits := api.Hits("/v1.svc/users"); hits != 1 {
+		t.Errorf("users were listed %d times, want 1", hits)
+	}
+}
+
+func TestUserFilterAccessLevels(t *testing.T) {
+	filter := &userFilter{accessLevels: []string{" team leader", "ADMINISTRATOR"}}
+	for _, tt := range []struct {
+		level string
+		want  bool
+	}{
+		{litmos.AccessLevelTeamLeader, true},
+		{litmos.AccessLevelAdministrator, true},
+		{litmos.AccessLevelLearner, false},
+	} {
+		if got := filter.Allows(&litmos.User{AccessLevel: tt.level}); got != tt.want {
+			t.Errorf("Allows(%s) = %t, want %t", tt.level, got, tt.want)
+		}
+	}
+
+	_, err := New(context.Background(), Config{APIKey: "key", Source: "test", UserAccessLevels: []string{"learner", "Team Lead"}})
+	if err == nil {
+		t.Error("New accepted an unknown access level")
+	}
+	_, err = New(context.Background(), Config{APIKey: "key", Source: "test", UserAccessLevels: []string{"learner", "account owner"}})
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -2,23 +2,114 @@ package connector
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
 
 	"github.com/conductorone/baton-litmos/pkg/litmos"
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
+	"github.com/conductorone/baton-sdk/pkg/crypto"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
+	"github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
 	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	mapset "github.com/deckarep/golang-set/v2"
 )
 
+const activeEntitlement = "active"
+
+// accountCreationSchema describes the account info fields read by CreateAccount. The keys under "profile" match the
+// keys of the synced user profile.
+var accountCreationSchema = map[string]interface{}{
+	"login": map[string]interface{}{
+		"display_name": "UserName",
+		"description":  "Litmos UserName, defaults to the primary email",
+		"required":     false,
+	},
+	"email": map[string]interface{}{
+		"display_name": "Email",
+		"description":  "Primary email address of the user",
+		"required":     true,
+	},
+	"profile": map[string]interface{}{
+		"first_name": map[string]interface{}{
+			"display_name": "FirstName",
+			"required":     true,
+		},
+		"last_name": map[string]interface{}{
+			"display_name": "LastName",
+			"required":     true,
+		},
+		"access_level": map[string]interface{}{
+			"display_name": "AccessLevel",
+			"required":     true,
+			"options": []interface{}{
+				litmos.AccessLevelLearner,
+				litmos.AccessLevelTeamLeader,
+				litmos.AccessLevelAdministrator,
+			},
+		},
+		"brand": map[string]interface{}{
+			"display_name": "Brand",
+			"required":     false,
+		},
+		"disable_messages": map[string]interface{}{
+			"display_name": "DisableMessages",
+			"description":  "Stop Litmos from emailing the user",
+			"required":     false,
+		},
+	},
+}
+
 type userBuilder struct {
 	client litmos.Client
+	// details enriches listed users with their full record, nil when enrichment is off.
+	details    *userDetails
+	settings   userSettings
+	userFilter *userFilter
+}
+
+// userSettings controls how Litmos users are turned into resources, wherever the connector builds one.
+type userSettings struct {
+	// customFields are copied into the profile. Custom fields are only known for users fetched with GetUser.
+	customFields []customFieldMapping
+	// profileKeys lists the profile keys that are kept, nil keeps all of them.
+	profileKeys mapset.Set[string]
+	// identity replaces the email and display name of users, one of the userIdentity constants.
+	identity string
+	// hashKey keys the pseudonyms of the hash identity.
+	hashKey []byte
+}
+
+// Ways to replace the email and display name of users. The hash also replaces the login.
+const (
+	userIdentityKeep  = ""
+	userIdentityLogin = "login"
+	userIdentityHash  = "hash"
+)
+
+// minimizing reports whether personal data is left out of the synced resources.
+func (s userSettings) minimizing() bool {
+	return s.profileKeys != nil || s.identity != userIdentityKeep
+}
+
+// identityHash returns a stable pseudonym for the value, so that users can still be told apart without copying
+// their email address. The hash is keyed, so the pseudonym of a known address cannot be computed without the key.
+func identityHash(key []byte, value string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(value))))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
 }
 
 func (o *userBuilder) ResourceType(ctx context.Context) *v2.ResourceType {
 	return userResourceType
 }
 
-func userResource(ctx context.Context, user *litmos.User, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+func userResource(ctx context.Context, user *litmos.User, parentResourceID *v2.ResourceId, settings userSettings) (*v2.Resource, error) {
 	profile := map[string]interface{}{
 		"first_name":   user.FirstName,
 		"last_name":    user.LastName,
@@ -26,21 +117,73 @@ func userResource(ctx context.Context, user *litmos.User, parentResourceID *v2.R
 		"brand":        user.Brand,
 		"access_level": user.AccessLevel,
 	}
+	// Details are only known for users fetched with GetUser, empty values are left out. The user trait of baton-sdk
+	// v0.2.42 has no employee IDs, so the external ID is only kept in the profile as employee_id.
+	for key, value := range map[string]string{
+		"job_title":     user.JobTitle,
+		"company_name":  user.CompanyName,
+		"department":    user.Department,
+		"manager_id":    user.ManagerId,
+		"manager_name":  user.ManagerName,
+		"city":          user.City,
+		"state":         user.State,
+		"country":       user.Country,
+		"employee_id":   user.ExternalId,
+		"salesforce_id": user.SalesforceId,
+	} {
+		if value != "" {
+			profile[key] = value
+		}
+	}
+	for key, value := range customFieldProfile(user, settings.customFields) {
+		profile[key] = value
+	}
+
+	// access_level is always kept, the role grants of the user are derived from it.
+	if settings.profileKeys != nil {
+		for key := range profile {
+			if key != "access_level" && !settings.profileKeys.Contains(key) {
+				delete(profile, key)
+			}
+		}
+	}
+
+	displayName := user.UserName
+	login := user.UserName
+	email := user.Email
+	switch settings.identity {
+	case userIdentityLogin:
+		email = user.UserName
+	case userIdentityHash:
+		// The login is usually an email address as well, so it is hashed too. A missing email stays missing, hashing
+		// it would give every such user the same email.
+		login = identityHash(settings.hashKey, user.UserName)
+		displayName = login
+		if email != "" {
+			email = identityHash(settings.hashKey, email)
+		}
+	}
 
 	status := rs.WithStatus(v2.UserTrait_Status_STATUS_ENABLED)
-	if !user.Active {
+	if user.Active.Valid && !user.Active.Bool {
 		status = rs.WithStatus(v2.UserTrait_Status_STATUS_DISABLED)
 	}
 
 	userTraitOptions := []rs.UserTraitOption{
 		rs.WithUserProfile(profile),
-		rs.WithUserLogin(user.UserName),
-		rs.WithEmail(user.Email, true),
+		rs.WithUserLogin(login),
+		rs.WithEmail(email, true),
 		status,
 	}
+	if user.CreatedDate.Valid {
+		userTraitOptions = append(userTraitOptions, rs.WithCreatedAt(user.CreatedDate.Time))
+	}
+	if user.LastLogin.Valid {
+		userTraitOptions = append(userTraitOptions, rs.WithLastLogin(user.LastLogin.Time))
+	}
 
 	resource, err := rs.NewUserResource(
-		user.UserName,
+		displayName,
 		userResourceType,
 		user.Id,
 		userTraitOptions,
@@ -57,34 +200,221 @@ func userResource(ctx context.Context, user *litmos.User, parentResourceID *v2.R
 // List returns all the users from the database as resource objects.
 // Users include a UserTrait because they are the 'shape' of a standard user.
 func (o *userBuilder) List(ctx context.Context, parentResourceID *v2.ResourceId, pToken *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
-	users, nextPageToken, err := o.client.ListUsers(ctx, pToken)
+	// The first page starts a new sync, users cached by an earlier one may be stale.
+	if pToken.Token == "" {
+		if o.details != nil {
+			o.details.Reset()
+		}
+		o.userFilter.Reset()
+	}
+
+	users, nextPageToken, rateLimit, err := o.client.ListUsers(ctx, pToken)
 	if err != nil {
 		return nil, nextPageToken, nil, err
 	}
 
 	resources := make([]*v2.Resource, 0, len(users))
 	for _, user := range users {
-		resource, err := userResource(ctx, &user, parentResourceID)
+		if !o.userFilter.Allows(&user) {
+			continue
+		}
+		u := &user
+		if o.details != nil {
+			u, err = o.details.Get(ctx, &user)
+			if err != nil {
+				return nil, "", nil, fmt.Errorf("litmos-connector: failed to get details of user %s: %w", user.Id, err)
+			}
+		}
+		resource, err := userResource(ctx, u, parentResourceID, o.settings)
 		if err != nil {
 			return nil, "", nil, err
 		}
 		resources = append(resources, resource)
 	}
-	return resources, nextPageToken, nil, nil
+	return resources, nextPageToken, rateLimitAnnotations(rateLimit), nil
 }
 
-// Entitlements always returns an empty slice for users.
+// Entitlements returns the active entitlement, which users hold on themselves while their account is enabled.
 func (o *userBuilder) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
-	return nil, "", nil, nil
+	activeOptions := []entitlement.EntitlementOption{
+		entitlement.WithGrantableTo(userResourceType),
+		entitlement.WithDisplayName(fmt.Sprintf("User %s %s", resource.DisplayName, activeEntitlement)),
+		entitlement.WithDescription(fmt.Sprintf("User %s is active in Litmos", resource.DisplayName)),
+	}
+
+	return []*v2.Entitlement{
+		entitlement.NewPermissionEntitlement(
+			resource,
+			activeEntitlement,
+			activeOptions...,
+		),
+	}, "", nil, nil
 }
 
-// Grants always returns an empty slice for users since they don't have any entitlements.
+// Grants returns the active grant for enabled users and the grant of the role matching the access level of the user.
+// Both come from the synced user trait, so no extra API calls are made.
 func (o *userBuilder) Grants(ctx context.Context, resource *v2.Resource, pToken *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
-	return nil, "", nil, nil
+	userTrait, err := rs.GetUserTrait(resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	var rv []*v2.Grant
+	if userTrait.GetStatus().GetStatus() == v2.UserTrait_Status_STATUS_ENABLED {
+		rv = append(rv, grant.NewGrant(resource, activeEntitlement, resource.Id))
+	}
+
+	accessLevel, _ := rs.GetProfileStringValue(userTrait.GetProfile(), "access_level")
+	role, err := roleGrant(resource, accessLevel)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if role != nil {
+		rv = append(rv, role)
+	}
+
+	return rv, "", nil, nil
+}
+
+// Grant reactivates a deactivated user. The active entitlement can only be granted to the user it belongs to.
+func (o *userBuilder) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
+	if slug := entitlementSlug(entitlement); slug != activeEntitlement {
+		return nil, fmt.Errorf("litmos-connector: entitlement %s cannot be provisioned", slug)
+	}
+
+	if principal.Id.ResourceType != userResourceType.Id || principal.Id.Resource != entitlement.Resource.Id.Resource {
+		return nil, fmt.Errorf("litmos-connector: the active entitlement can only be granted to the user it belongs to")
+	}
+
+	err := o.client.SetUserActive(ctx, principal.Id.Resource, true)
+	if err != nil {
+		return nil, fmt.Errorf("litmos-connector: failed to activate user: %w", err)
+	}
+
+	return nil, nil
+}
+
+// Revoke deactivates the user. The account and its training history are kept in Litmos.
+func (o *userBuilder) Revoke(ctx context.Context, g *v2.Grant) (annotations.Annotations, error) {
+	entitlement := g.Entitlement
+	principal := g.Principal
+
+	if slug := entitlementSlug(entitlement); slug != activeEntitlement {
+		return nil, fmt.Errorf("litmos-connector: entitlement %s cannot be provisioned", slug)
+	}
+
+	if principal.Id.ResourceType != userResourceType.Id || principal.Id.Resource != entitlement.Resource.Id.Resource {
+		return nil, fmt.Errorf("litmos-connector: the active entitlement can only be revoked from the user it belongs to")
+	}
+
+	err := o.client.SetUserActive(ctx, principal.Id.Resource, false)
+	if err != nil {
+		return nil, fmt.Errorf("litmos-connector: failed to deactivate user: %w", err)
+	}
+
+	return nil, nil
+}
+
+func newUserFromAccountInfo(accountInfo *v2.AccountInfo) (*litmos.NewUser, error) {
+	profile := accountInfo.GetProfile().AsMap()
+	profileString := func(key string) string {
+		v, _ := profile[key].(string)
+		return strings.TrimSpace(v)
+	}
+
+	email := profileString("email")
+	for _, e := range accountInfo.GetEmails() {
+		if e.GetIsPrimary() || email == "" {
+			email = e.GetAddress()
+		}
+	}
+	if email == "" {
+		return nil, fmt.Errorf("litmos-connector: email is required")
+	}
+
+	userName := accountInfo.GetLogin()
+	if userName == "" {
+		userName = email
+	}
+
+	firstName := profileString("first_name")
+	lastName := profileString("last_name")
+	if firstName == "" || lastName == "" {
+		return nil, fmt.Errorf("litmos-connector: first_name and last_name are required")
+	}
+
+	accessLevel := profileString("access_level")
+	switch accessLevel {
+	case litmos.AccessLevelLearner, litmos.AccessLevelTeamLeader, litmos.AccessLevelAdministrator:
+	case "":
+		return nil, fmt.Errorf("litmos-connector: access_level is required")
+	default:
+		return nil, fmt.Errorf("litmos-connector: unsupported access_level %q", accessLevel)
+	}
+
+	disableMessages, _ := profile["disable_messages"].(bool)
+
+	return &litmos.NewUser{
+		UserName:        userName,
+		FirstName:       firstName,
+		LastName:        lastName,
+		FullName:        strings.TrimSpace(firstName + " " + lastName),
+		Email:           email,
+		AccessLevel:     accessLevel,
+		Brand:           profileString("brand"),
+		DisableMessages: disableMessages,
+		Active:          true,
+	}, nil
+}
+
+// CreateAccount creates a Litmos user. When a random password is requested it is set on the account and returned to
+// the caller, otherwise Litmos sends its own login instructions.
+func (o *userBuilder) CreateAccount(
+	ctx context.Context,
+	accountInfo *v2.AccountInfo,
+	credentialOptions *v2.CredentialOptions,
+) (connectorbuilder.CreateAccountResponse, []*v2.PlaintextData, annotations.Annotations, error) {
+	newUser, err := newUserFromAccountInfo(accountInfo)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var plaintexts []*v2.PlaintextData
+	if credentialOptions.GetRandomPassword() != nil {
+		password, err := crypto.GeneratePassword(credentialOptions)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		newUser.Password = password
+		newUser.SkipFirstLogin = true
+		plaintexts = append(plaintexts, &v2.PlaintextData{
+			Name:        "password",
+			Description: "Litmos password",
+			Bytes:       []byte(password),
+		})
+	}
+
+	user, err := o.client.CreateUser(ctx, newUser)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("litmos-connector: failed to create user: %w", err)
+	}
+
+	resource, err := userResource(ctx, user, nil, o.settings)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return &v2.CreateAccountResponse_SuccessResult{
+		Resource:              resource,
+		IsCreateAccountResult: true,
+	}, plaintexts, nil, nil
 }
 
-func newUserBuilder(client litmos.Client) *userBuilder {
+func newUserBuilder(client litmos.Client, details *userDetails, settings userSettings, userFilter *userFilter) *userBuilder {
 	return &userBuilder{
-		client: client,
+		client:     client,
+		details:    details,
+		settings:   settings,
+		userFilter: userFilter,
 	}
 }
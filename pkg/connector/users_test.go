@@ -0,0 +1,74 @@
+package connector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/conductorone/baton-litmos/pkg/litmos"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+)
+
+func TestUserIdentity(t *testing.T) {
+	ada := &litmos.User{Id: "u1", UserName: "ada@example.com", Email: "ada.lovelace@example.com"}
+	grace := &litmos.User{Id: "u2", UserName: "grace@example.com"}
+	key := []byte("secret")
+
+	tests := []struct {
+		name        string
+		identity    string
+		user        *litmos.User
+		displayName string
+		login       string
+		email       string
+	}{
+		{"keep", userIdentityKeep, ada, "ada@example.com", "ada@example.com", "ada.lovelace@example.com"},
+		{"login", userIdentityLogin, ada, "ada@example.com", "ada@example.com", "ada@example.com"},
+		{"hash", userIdentityHash, ada, identityHash(key, "ada@example.com"), identityHash(key, "ada@example.com"), identityHash(key, "ada.lovelace@example.com")},
+		{"hash without email", userIdentityHash, grace, identityHash(key, "grace@example.com"), identityHash(key, "grace@example.com"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource, err := userResource(context.Background(), tt.user, nil, userSettings{identity: tt.identity, hashKey: key})
+			if err != nil {
+				t.Fatal(err)
+			}
+			trait, err := rs.GetUserTrait(resource)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resource.DisplayName != tt.displayName {
+				t.Errorf("display name = %q, want %q", resource.DisplayName, tt.displayName)
+			}
+			if trait.Login != tt.login {
+				t.Errorf("login = %q, want %q", trait.Login, tt.login)
+			}
+			var email string
+			if len(trait.Emails) > 0 {
+				email = trait.Emails[0].Address
+			}
+			if email != tt.email {
+				t.Errorf("email = %q, want %q", email, tt.email)
+			}
+		})
+	}
+}
+
+func TestIdentityHashIsKeyed(t *testing.T) {
+	a, b := identityHash([]byte("key a"), "ada@example.com"), identityHash([]byte("key b"), "ada@example.com")
+	if a == b {
+		t.Errorf("both keys give the pseudonym %s", a)
+	}
+	if got := identityHash([]byte("key a"), " ADA@example.com "); got != a {
+		t.Errorf("pseudonym %s depends on case and spacing, want %s", got, a)
+	}
+
+	_, err := New(context.Background(), Config{APIKey: "key", Source: "test", UserIdentity: userIdentityHash})
+	if err == nil {
+		t.Error("New accepted the hash identity without a key")
+	}
+	_, err = New(context.Background(), Config{APIKey: "key", Source: "test", UserIdentity: userIdentityHash, UserIdentityHashKey: "secret"})
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -1,13 +1,19 @@
 package litmos
 
 import (
+	"bytes"
 	"context"
 	"encoding/xml"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strconv"
+	"sync"
+	"time"
 
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
 	"github.com/conductorone/baton-sdk/pkg/uhttp"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
@@ -16,16 +22,45 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-// TODO: make this configurable. litmos API allows for page sizes up to 1,000
-const pageSize = 500
+const defaultPageSize = 500
+const maxPageSize = 1000
 
 type Client struct {
 	wrapper *uhttp.BaseHttpClient
-	apiKey  string
-	source  string
+	// uncached sends GET requests past the HTTP cache, for reads that must see the current state in Litmos.
+	uncached *uhttp.BaseHttpClient
+	// truncated holds the URLs whose cached response failed to decode. They are read past the cache from then on, as
+	// the cache has no way to drop a single entry.
+	truncated   *sync.Map
+	apiKey      string
+	source      string
+	baseURL     *url.URL
+	pageSize    int
+	limiter     *tokenBucket
+	retryPolicy retryPolicy
 }
 
-func NewClient(ctx context.Context, apiKey, source string) (*Client, error) {
+func NewClient(ctx context.Context, apiKey, source string, opts ...Option) (*Client, error) {
+	baseURL, err := ParseBaseURL(defaultBaseURL)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		apiKey:      apiKey,
+		source:      source,
+		baseURL:     baseURL,
+		pageSize:    defaultPageSize,
+		limiter:     newTokenBucket(defaultRequestsPerMinute),
+		retryPolicy: newRetryPolicy(defaultMaxRetries),
+		truncated:   &sync.Map{},
+	}
+	for _, opt := range opts {
+		err := opt(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	options := []uhttp.Option{uhttp.WithLogger(true, nil)}
 
 	httpClient, err := uhttp.NewClient(ctx, options...)
@@ -38,14 +73,29 @@ func NewClient(ctx context.Context, apiKey, source string) (*Client, error) {
 		return nil, fmt.Errorf("creating HTTP wrapper failed: %w", err)
 	}
 
-	return &Client{
-		wrapper: wrapper,
-		apiKey:  apiKey,
-		source:  source,
-	}, nil
+	c.wrapper = wrapper
+
+	uncachedCtx := context.WithValue(ctx, uhttp.ContextKey{}, uhttp.CacheConfig{DisableCache: true})
+	uncached, err := uhttp.NewBaseHttpClientWithContext(uncachedCtx, httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("creating HTTP wrapper failed: %w", err)
+	}
+
+	c.uncached = uncached
+
+	return c, nil
 }
 
 func (c *Client) Do(ctx context.Context, method string, path string, query *url.Values, response interface{}, options ...uhttp.RequestOption) (*http.Response, error) {
+	return c.do(ctx, method, path, query, response, true, options...)
+}
+
+// doUncached is Do for reads that must not be served from the HTTP cache, such as the record read before an update.
+func (c *Client) doUncached(ctx context.Context, method string, path string, query *url.Values, response interface{}, options ...uhttp.RequestOption) (*http.Response, error) {
+	return c.do(ctx, method, path, query, response, false, options...)
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, query *url.Values, response interface{}, cached bool, options ...uhttp.RequestOption) (*http.Response, error) {
 	l := ctxzap.Extract(ctx)
 	options = append(options,
 		uhttp.WithHeader("apikey", c.apiKey), uhttp.WithAcceptXMLHeader(),
@@ -55,31 +105,132 @@ func (c *Client) Do(ctx context.Context, method string, path string, query *url.
 	if query != nil {
 		rawQuery = query.Encode()
 	}
-	url := &url.URL{
-		Scheme:   "https",
-		Host:     "api.litmos.com",
-		Path:     path,
-		RawQuery: rawQuery,
-	}
+	url := c.baseURL.JoinPath(path)
+	url.RawQuery = rawQuery
 	q := url.Query()
 	q.Add("source", c.source)
 	url.RawQuery = q.Encode()
 
-	req, err := c.wrapper.NewRequest(ctx, method, url, options...)
-	if err != nil {
-		return nil, err
+	var doOptions []uhttp.DoOption
+	if response != nil {
+		doOptions = append(doOptions, uhttp.WithXMLResponse(response))
+	}
+
+	if _, ok := c.truncated.Load(url.String()); ok {
+		cached = false
 	}
-	l.Debug("sending request", zap.String("url", url.String()), zap.String("method", method))
-	resp, err := c.wrapper.Do(req, uhttp.WithXMLResponse(response))
-	if err != nil && resp != nil {
-		// Retry 503s & 504s because the Litmos API is flaky
-		if resp.StatusCode == http.StatusGatewayTimeout || resp.StatusCode == http.StatusServiceUnavailable {
+
+	start := time.Now()
+	rateLimitRetries := 0
+	retries := 0
+	for {
+		if c.limiter != nil {
+			err := c.limiter.Wait(ctx)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// The request is rebuilt on every attempt because sending it consumes the body, and the response is reset so a
+		// partial decode from a failed attempt does not leak into the next one.
+		req, err := c.wrapper.NewRequest(ctx, method, url, options...)
+		if err != nil {
+			return nil, err
+		}
+		if response != nil {
+			v := reflect.ValueOf(response).Elem()
+			v.Set(reflect.Zero(v.Type()))
+		}
+		l.Debug("sending request", zap.String("url", url.String()), zap.String("method", method))
+		wrapper := c.wrapper
+		if !cached {
+			wrapper = c.uncached
+		}
+		resp, err := wrapper.Do(req, doOptions...)
+		if err == nil {
+			return resp, nil
+		}
+		if cached && truncatedBody(resp, err) {
+			c.truncated.Store(url.String(), struct{}{})
+			cached = false
+		}
+
+		// A 429 means Litmos did not process the request, so it is safe to send it again once the quota resets.
+		if resp != nil && resp.StatusCode == http.StatusTooManyRequests && rateLimitRetries < maxRateLimitRetries {
+			rateLimitRetries++
+			if c.limiter != nil {
+				c.limiter.Drain()
+			}
+			wait := retryAfter(resp)
+			l.Warn(
+				"litmos rate limit exceeded, waiting before retrying",
+				zap.String("url", url.String()),
+				zap.Duration("wait", wait),
+				zap.Int("attempt", rateLimitRetries),
+			)
+			if !sleep(ctx, wait) {
+				return resp, err
+			}
+			continue
+		}
+
+		// Retry transient failures because the Litmos API is flaky. Only reads are retried, a write may have been
+		// applied even though the response was lost.
+		if method == http.MethodGet && retries < c.retryPolicy.maxRetries {
+			if reason, ok := transientFailure(resp, err); ok {
+				wait := c.retryPolicy.backoff(retries)
+				if time.Since(start)+wait <= c.retryPolicy.deadline {
+					retries++
+					l.Warn(
+						"litmos request failed, retrying",
+						zap.String("url", url.String()),
+						zap.String("reason", reason),
+						zap.Duration("wait", wait),
+						zap.Int("attempt", retries),
+						zap.Error(err),
+					)
+					if !sleep(ctx, wait) {
+						return resp, err
+					}
+					continue
+				}
+			}
+		}
+
+		if resp != nil && (resp.StatusCode == http.StatusGatewayTimeout || resp.StatusCode == http.StatusServiceUnavailable) {
 			return resp, status.Error(codes.Unavailable, resp.Status)
 		}
+		return resp, err
+	}
+}
+
+// sleep waits for d, returning false if the context is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// withXMLBody encodes body as the XML request payload. The Litmos API does not
+// accept JSON for write operations.
+func withXMLBody(body interface{}) uhttp.RequestOption {
+	return func() (io.ReadWriter, map[string]string, error) {
+		buffer := new(bytes.Buffer)
+		err := xml.NewEncoder(buffer).Encode(body)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return buffer, map[string]string{
+			uhttp.ContentType: "application/xml",
+		}, nil
 	}
-	return resp, err
 }
 
+// PaginationInfo is the paging envelope Litmos includes in list responses.
 type PaginationInfo struct {
 	BatchParam string `xml:"BatchParam"`
 	BatchSize  int    `xml:"BatchSize"`
@@ -87,9 +238,9 @@ type PaginationInfo struct {
 	TotalCount int    `xml:"TotalCount"`
 }
 
-func pageTokenToQuery(pToken *pagination.Token) *url.Values {
+func (c *Client) pageTokenToQuery(pToken *pagination.Token) *url.Values {
 	query := &url.Values{}
-	query.Add("limit", strconv.Itoa(pageSize))
+	query.Add("limit", strconv.Itoa(c.pageSize))
 
 	if pToken == nil || pToken.Token == "" {
 		return query
@@ -104,53 +255,193 @@ func pageTokenToQuery(pToken *pagination.Token) *url.Values {
 	return query
 }
 
-func getNextPageToken(pToken *pagination.Token, numItems int) string {
-	if pToken == nil {
-		return ""
-	}
-
-	if numItems < pageSize {
-		// no more pages
-		return ""
+// getNextPageToken returns the start offset of the next page, which follows the items of this page. When the response
+// carries a pagination envelope, paging stops once those items reach TotalCount, and a page that does not begin at the
+// requested offset is reported as an error, since a server that ignores start would otherwise be paged forever.
+// Without an envelope, a short page marks the end. An empty page always ends paging.
+func (c *Client) getNextPageToken(pToken *pagination.Token, numItems int, info *PaginationInfo) (string, error) {
+	if pToken == nil || numItems == 0 {
+		return "", nil
 	}
 
-	if pToken.Token == "" {
-		return strconv.Itoa(numItems)
+	start := 0
+	if pToken.Token != "" {
+		var err error
+		start, err = strconv.Atoi(pToken.Token)
+		if err != nil {
+			return "", nil
+		}
 	}
 
-	start, err := strconv.Atoi(pToken.Token)
-	if err != nil {
-		return ""
+	if info != nil {
+		if info.Start != start {
+			return "", fmt.Errorf("litmos: requested page at offset %d but got offset %d", start, info.Start)
+		}
+		if start+numItems >= info.TotalCount {
+			return "", nil
+		}
+	} else if numItems < c.pageSize {
+		// no more pages
+		return "", nil
 	}
 
-	return strconv.Itoa(start + numItems)
+	return strconv.Itoa(start + numItems), nil
 }
 
 type User struct {
-	Id          string `xml:"Id"`
-	UserName    string `xml:"UserName"`
-	FirstName   string `xml:"FirstName"`
-	LastName    string `xml:"LastName"`
-	Active      bool   `xml:"Active"`
-	Email       string `xml:"Email"`
-	AccessLevel string `xml:"AccessLevel"`
-	Brand       string `xml:"Brand"`
+	Id              string `xml:"Id"`
+	UserName        string `xml:"UserName"`
+	FirstName       string `xml:"FirstName"`
+	LastName        string `xml:"LastName"`
+	FullName        string `xml:"FullName,omitempty"`
+	Active          Bool   `xml:"Active"`
+	Email           string `xml:"Email"`
+	AccessLevel     string `xml:"AccessLevel"`
+	Brand           string `xml:"Brand"`
+	DisableMessages Bool   `xml:"DisableMessages"`
+	SkipFirstLogin  Bool   `xml:"SkipFirstLogin"`
+	TimeZone        string `xml:"TimeZone,omitempty"`
+	PhoneWork       string `xml:"PhoneWork,omitempty"`
+	PhoneMobile     string `xml:"PhoneMobile,omitempty"`
+
+	// The fields below are only returned by GetUser.
+	JobTitle     string        `xml:"JobTitle,omitempty"`
+	CompanyName  string        `xml:"CompanyName,omitempty"`
+	Department   string        `xml:"Department,omitempty"`
+	ManagerId    string        `xml:"ManagerId,omitempty"`
+	ManagerName  string        `xml:"ManagerName,omitempty"`
+	Street1      string        `xml:"Street1,omitempty"`
+	Street2      string        `xml:"Street2,omitempty"`
+	City         string        `xml:"City,omitempty"`
+	State        string        `xml:"State,omitempty"`
+	PostalCode   string        `xml:"PostalCode,omitempty"`
+	Country      string        `xml:"Country,omitempty"`
+	Culture      string        `xml:"Culture,omitempty"`
+	ExternalId   string        `xml:"ExternalId,omitempty"`
+	SalesforceId string        `xml:"SalesforceId,omitempty"`
+	CreatedDate  Time          `xml:"CreatedDate"`
+	LastLogin    Time          `xml:"LastLogin"`
+	CustomFields []CustomField `xml:"CustomFields>CustomField,omitempty"`
+
+	// Other keeps the elements of the record that are not modeled above, so that UpdateUser does not reset them.
+	Other []rawElement `xml:",any"`
+}
+
+// rawElement is an XML element kept verbatim.
+type rawElement struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Inner   string     `xml:",innerxml"`
+}
+
+// CustomField is a tenant defined attribute of a user.
+type CustomField struct {
+	Name  string `xml:"Name"`
+	Value string `xml:"Value"`
 }
 type UsersResp struct {
-	XMLName xml.Name `xml:"Users"`
-	Users   []User   `xml:"User"`
+	XMLName    xml.Name        `xml:"Users"`
+	Users      []User          `xml:"User"`
+	Pagination *PaginationInfo `xml:"Pagination"`
+}
+
+// Access levels a Litmos user can hold.
+const (
+	AccessLevelLearner       = "Learner"
+	AccessLevelTeamLeader    = "Team Leader"
+	AccessLevelAdministrator = "Administrator"
+	AccessLevelAccountOwner  = "Account Owner"
+)
+
+type NewUser struct {
+	XMLName         xml.Name `xml:"User"`
+	Id              string   `xml:"Id"`
+	UserName        string   `xml:"UserName"`
+	FirstName       string   `xml:"FirstName"`
+	LastName        string   `xml:"LastName"`
+	FullName        string   `xml:"FullName"`
+	Email           string   `xml:"Email"`
+	AccessLevel     string   `xml:"AccessLevel"`
+	Brand           string   `xml:"Brand,omitempty"`
+	DisableMessages bool     `xml:"DisableMessages"`
+	Active          bool     `xml:"Active"`
+	Password        string   `xml:"Password,omitempty"`
+	SkipFirstLogin  bool     `xml:"SkipFirstLogin"`
+}
+
+func (c *Client) CreateUser(ctx context.Context, user *NewUser) (*User, error) {
+	userResp := User{}
+	_, err := c.Do(ctx, http.MethodPost, "/v1.svc/users", nil, &userResp, withXMLBody(user))
+	if err != nil {
+		return nil, err
+	}
+
+	return &userResp, nil
+}
+
+func (c *Client) ListUsers(ctx context.Context, pToken *pagination.Token) ([]User, string, *v2.RateLimitDescription, error) {
+	usersResp := UsersResp{}
+	query := c.pageTokenToQuery(pToken)
+	httpResp, err := c.Do(ctx, "GET", "/v1.svc/users", query, &usersResp)
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+
+	nextPageToken, err := c.getNextPageToken(pToken, len(usersResp.Users), usersResp.Pagination)
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+	return usersResp.Users, nextPageToken, c.rateLimitDescription(httpResp), nil
 }
 
-func (c *Client) ListUsers(ctx context.Context, pToken *pagination.Token) ([]User, string, error) {
+// CheckAccess fetches a single user to verify that the API key and source are accepted by Litmos.
+func (c *Client) CheckAccess(ctx context.Context) error {
 	usersResp := UsersResp{}
-	query := pageTokenToQuery(pToken)
+	query := &url.Values{}
+	query.Add("limit", "1")
 	_, err := c.Do(ctx, "GET", "/v1.svc/users", query, &usersResp)
+	return err
+}
+
+// GetUser returns the full record of the user. The record is always read from Litmos and not from the HTTP cache, as
+// it is the base of read-modify-write updates.
+func (c *Client) GetUser(ctx context.Context, userId string) (*User, error) {
+	userResp := User{}
+	path, err := url.JoinPath("/v1.svc/users", userId)
+	if err != nil {
+		return nil, err
+	}
+	_, err = c.doUncached(ctx, "GET", path, nil, &userResp)
 	if err != nil {
-		return nil, pToken.Token, err
+		return nil, err
 	}
 
-	nextPageToken := getNextPageToken(pToken, len(usersResp.Users))
-	return usersResp.Users, nextPageToken, nil
+	return &userResp, nil
+}
+
+// UpdateUser replaces the user record in Litmos. Litmos expects the complete record, so callers should start from
+// GetUser rather than from a list response. Elements of the record that User does not model are sent back as read.
+func (c *Client) UpdateUser(ctx context.Context, user *User) error {
+	path, err := url.JoinPath("/v1.svc/users", user.Id)
+	if err != nil {
+		return err
+	}
+	_, err = c.Do(ctx, http.MethodPut, path, nil, nil, withXMLBody(user))
+	return err
+}
+
+// SetUserActive activates or deactivates the user. Deactivated users cannot log in, but keep their training history.
+func (c *Client) SetUserActive(ctx context.Context, userId string, active bool) error {
+	user, err := c.GetUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if user.Active.Valid && user.Active.Bool == active {
+		return nil
+	}
+
+	user.Active = NewBool(active)
+	return c.UpdateUser(ctx, user)
 }
 
 type Team struct {
@@ -160,70 +451,206 @@ type Team struct {
 	ParentTeamId          string `xml:"ParentTeamId"`
 }
 type TeamsResp struct {
-	XMLName xml.Name `xml:"Teams"`
-	Teams   []Team   `xml:"Team"`
+	XMLName    xml.Name        `xml:"Teams"`
+	Teams      []Team          `xml:"Team"`
+	Pagination *PaginationInfo `xml:"Pagination"`
 }
 
-func (c *Client) ListTeams(ctx context.Context, pToken *pagination.Token) ([]Team, string, error) {
+func (c *Client) ListTeams(ctx context.Context, pToken *pagination.Token) ([]Team, string, *v2.RateLimitDescription, error) {
 	teamsResp := TeamsResp{}
-	query := pageTokenToQuery(pToken)
-	_, err := c.Do(ctx, "GET", "/v1.svc/teams", query, &teamsResp)
+	query := c.pageTokenToQuery(pToken)
+	httpResp, err := c.Do(ctx, "GET", "/v1.svc/teams", query, &teamsResp)
 	if err != nil {
-		return nil, pToken.Token, err
+		return nil, pToken.Token, nil, err
 	}
 
-	nextPageToken := getNextPageToken(pToken, len(teamsResp.Teams))
-	return teamsResp.Teams, nextPageToken, nil
+	nextPageToken, err := c.getNextPageToken(pToken, len(teamsResp.Teams), teamsResp.Pagination)
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+	return teamsResp.Teams, nextPageToken, c.rateLimitDescription(httpResp), nil
 }
 
-func (c *Client) ListTeamUsers(ctx context.Context, pToken *pagination.Token, teamId string) ([]User, string, error) {
+// ListSubTeams lists the teams directly below the team in the team hierarchy.
+func (c *Client) ListSubTeams(ctx context.Context, pToken *pagination.Token, teamId string) ([]Team, string, *v2.RateLimitDescription, error) {
+	teamsResp := TeamsResp{}
+	query := c.pageTokenToQuery(pToken)
+	path, err := url.JoinPath("/v1.svc/teams", teamId, "teams")
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+	httpResp, err := c.Do(ctx, "GET", path, query, &teamsResp)
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+
+	nextPageToken, err := c.getNextPageToken(pToken, len(teamsResp.Teams), teamsResp.Pagination)
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+	return teamsResp.Teams, nextPageToken, c.rateLimitDescription(httpResp), nil
+}
+
+func (c *Client) ListTeamUsers(ctx context.Context, pToken *pagination.Token, teamId string) ([]User, string, *v2.RateLimitDescription, error) {
 	usersResp := UsersResp{}
-	query := pageTokenToQuery(pToken)
+	query := c.pageTokenToQuery(pToken)
 	path, err := url.JoinPath("/v1.svc/teams", teamId, "users")
 	if err != nil {
-		return nil, pToken.Token, err
+		return nil, pToken.Token, nil, err
+	}
+	httpResp, err := c.Do(ctx, "GET", path, query, &usersResp)
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+
+	nextPageToken, err := c.getNextPageToken(pToken, len(usersResp.Users), usersResp.Pagination)
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+	return usersResp.Users, nextPageToken, c.rateLimitDescription(httpResp), nil
+}
+
+func (c *Client) ListTeamLeaders(ctx context.Context, pToken *pagination.Token, teamId string) ([]User, string, *v2.RateLimitDescription, error) {
+	usersResp := UsersResp{}
+	query := c.pageTokenToQuery(pToken)
+	path, err := url.JoinPath("/v1.svc/teams", teamId, "leaders")
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+	httpResp, err := c.Do(ctx, "GET", path, query, &usersResp)
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+
+	nextPageToken, err := c.getNextPageToken(pToken, len(usersResp.Users), usersResp.Pagination)
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+	return usersResp.Users, nextPageToken, c.rateLimitDescription(httpResp), nil
+}
+
+func (c *Client) ListTeamAdmins(ctx context.Context, pToken *pagination.Token, teamId string) ([]User, string, *v2.RateLimitDescription, error) {
+	usersResp := UsersResp{}
+	query := c.pageTokenToQuery(pToken)
+	path, err := url.JoinPath("/v1.svc/teams", teamId, "admins")
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+	httpResp, err := c.Do(ctx, "GET", path, query, &usersResp)
+	if err != nil {
+		return nil, pToken.Token, nil, err
 	}
-	_, err = c.Do(ctx, "GET", path, query, &usersResp)
+
+	nextPageToken, err := c.getNextPageToken(pToken, len(usersResp.Users), usersResp.Pagination)
 	if err != nil {
-		return nil, pToken.Token, err
+		return nil, pToken.Token, nil, err
 	}
+	return usersResp.Users, nextPageToken, c.rateLimitDescription(httpResp), nil
+}
 
-	nextPageToken := getNextPageToken(pToken, len(usersResp.Users))
-	return usersResp.Users, nextPageToken, nil
+type UserRef struct {
+	Id string `xml:"Id"`
+}
+type UserRefsReq struct {
+	XMLName xml.Name  `xml:"Users"`
+	Users   []UserRef `xml:"User"`
+}
+
+func (c *Client) AddUserToTeam(ctx context.Context, teamId string, userId string) error {
+	path, err := url.JoinPath("/v1.svc/teams", teamId, "users")
+	if err != nil {
+		return err
+	}
+	body := UserRefsReq{
+		Users: []UserRef{{Id: userId}},
+	}
+	_, err = c.Do(ctx, http.MethodPost, path, nil, nil, withXMLBody(body))
+	return err
+}
+
+func (c *Client) RemoveUserFromTeam(ctx context.Context, teamId string, userId string) error {
+	path, err := url.JoinPath("/v1.svc/teams", teamId, "users", userId)
+	if err != nil {
+		return err
+	}
+	_, err = c.Do(ctx, http.MethodDelete, path, nil, nil)
+	return err
+}
+
+// PromoteTeamLeader makes a member of the team one of its leaders.
+func (c *Client) PromoteTeamLeader(ctx context.Context, teamId string, userId string) error {
+	path, err := url.JoinPath("/v1.svc/teams", teamId, "leaders", userId)
+	if err != nil {
+		return err
+	}
+	_, err = c.Do(ctx, http.MethodPost, path, nil, nil)
+	return err
+}
+
+func (c *Client) DemoteTeamLeader(ctx context.Context, teamId string, userId string) error {
+	path, err := url.JoinPath("/v1.svc/teams", teamId, "leaders", userId)
+	if err != nil {
+		return err
+	}
+	_, err = c.Do(ctx, http.MethodDelete, path, nil, nil)
+	return err
+}
+
+// PromoteTeamAdmin makes a member of the team one of its admins.
+func (c *Client) PromoteTeamAdmin(ctx context.Context, teamId string, userId string) error {
+	path, err := url.JoinPath("/v1.svc/teams", teamId, "admins", userId)
+	if err != nil {
+		return err
+	}
+	_, err = c.Do(ctx, http.MethodPost, path, nil, nil)
+	return err
+}
+
+func (c *Client) DemoteTeamAdmin(ctx context.Context, teamId string, userId string) error {
+	path, err := url.JoinPath("/v1.svc/teams", teamId, "admins", userId)
+	if err != nil {
+		return err
+	}
+	_, err = c.Do(ctx, http.MethodDelete, path, nil, nil)
+	return err
 }
 
 type Course struct {
 	Id                        string `xml:"Id"`
 	Code                      string `xml:"Code"`
 	Name                      string `xml:"Name"`
-	Active                    bool   `xml:"Active"`
-	ForSale                   bool   `xml:"ForSale"`
+	Active                    Bool   `xml:"Active"`
+	ForSale                   Bool   `xml:"ForSale"`
 	OriginalId                string `xml:"OriginalId"`
 	Description               string `xml:"Description"`
 	EcommerceShortDescription string `xml:"EcommerceShortDescription"`
 	EcommerceLongDescription  string `xml:"EcommerceLongDescription"`
 	CourseCodeForBulkImport   string `xml:"CourseCodeForBulkImport"`
 	Price                     string `xml:"Price"`
-	AccessTillDate            string `xml:"AccessTillDate"`
+	AccessTillDate            Time   `xml:"AccessTillDate"`
 	AccessTillDays            string `xml:"AccessTillDays"`
-	CourseTeamLibrary         bool   `xml:"CourseTeamLibrary"`
+	CourseTeamLibrary         Bool   `xml:"CourseTeamLibrary"`
 	CreatedBy                 string `xml:"CreatedBy"`
 	SeqId                     string `xml:"SeqId"`
 }
 type CoursesResp struct {
-	Courses []Course `xml:"Course"`
+	Courses    []Course        `xml:"Course"`
+	Pagination *PaginationInfo `xml:"Pagination"`
 }
 
-func (c *Client) ListCourses(ctx context.Context, pToken *pagination.Token) ([]Course, string, error) {
+func (c *Client) ListCourses(ctx context.Context, pToken *pagination.Token) ([]Course, string, *v2.RateLimitDescription, error) {
 	coursesResp := CoursesResp{}
-	query := pageTokenToQuery(pToken)
-	_, err := c.Do(ctx, "GET", "/v1.svc/courses", query, &coursesResp)
+	query := c.pageTokenToQuery(pToken)
+	httpResp, err := c.Do(ctx, "GET", "/v1.svc/courses", query, &coursesResp)
 	if err != nil {
-		return nil, pToken.Token, err
+		return nil, pToken.Token, nil, err
 	}
 
-	nextPageToken := getNextPageToken(pToken, len(coursesResp.Courses))
-	return coursesResp.Courses, nextPageToken, nil
+	nextPageToken, err := c.getNextPageToken(pToken, len(coursesResp.Courses), coursesResp.Pagination)
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+	return coursesResp.Courses, nextPageToken, c.rateLimitDescription(httpResp), nil
 }
 
 func (c *Client) GetCourse(ctx context.Context, courseId string) (*Course, error) {
@@ -236,36 +663,249 @@ func (c *Client) GetCourse(ctx context.Context, courseId string) (*Course, error
 	return &courseResp, nil
 }
 
+// ListTeamCourses returns the courses assigned to the team. Members of the team are enrolled in these courses.
+func (c *Client) ListTeamCourses(ctx context.Context, pToken *pagination.Token, teamId string) ([]Course, string, *v2.RateLimitDescription, error) {
+	coursesResp := CoursesResp{}
+	query := c.pageTokenToQuery(pToken)
+	path, err := url.JoinPath("/v1.svc/teams", teamId, "courses")
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+	httpResp, err := c.Do(ctx, "GET", path, query, &coursesResp)
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+
+	nextPageToken, err := c.getNextPageToken(pToken, len(coursesResp.Courses), coursesResp.Pagination)
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+	return coursesResp.Courses, nextPageToken, c.rateLimitDescription(httpResp), nil
+}
+
 type CourseUser struct {
-	Id                 string  `xml:"Id"`
-	UserName           string  `xml:"UserName"`
-	FirstName          string  `xml:"FirstName"`
-	LastName           string  `xml:"LastName"`
-	Completed          bool    `xml:"Completed"`
-	PercentageComplete float64 `xml:"PercentageComplete"`
-	CompliantTill      string  `xml:"CompliantTill"`
-	DueDate            string  `xml:"DueDate"`
-	AccessTillDate     string  `xml:"AccessTillDate"`
+	Id                 string `xml:"Id"`
+	UserName           string `xml:"UserName"`
+	FirstName          string `xml:"FirstName"`
+	LastName           string `xml:"LastName"`
+	Completed          Bool   `xml:"Completed"`
+	PercentageComplete Float  `xml:"PercentageComplete"`
+	CompliantTill      Time   `xml:"CompliantTill"`
+	DueDate            Time   `xml:"DueDate"`
+	AccessTillDate     Time   `xml:"AccessTillDate"`
 }
 type CourseUsersResp struct {
-	XMLName xml.Name     `xml:"Users"`
-	Users   []CourseUser `xml:"User"`
+	XMLName    xml.Name        `xml:"Users"`
+	Users      []CourseUser    `xml:"User"`
+	Pagination *PaginationInfo `xml:"Pagination"`
 }
 
-func (c *Client) ListCourseUsers(ctx context.Context, pToken *pagination.Token, courseId string) ([]CourseUser, string, error) {
+func (c *Client) ListCourseUsers(ctx context.Context, pToken *pagination.Token, courseId string) ([]CourseUser, string, *v2.RateLimitDescription, error) {
 	resp := CourseUsersResp{}
-	query := pageTokenToQuery(pToken)
+	query := c.pageTokenToQuery(pToken)
 	path, err := url.JoinPath("/v1.svc/courses", courseId, "users")
 	if err != nil {
-		return nil, pToken.Token, err
+		return nil, pToken.Token, nil, err
+	}
+	httpResp, err := c.Do(ctx, "GET", path, query, &resp)
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+
+	nextPageToken, err := c.getNextPageToken(pToken, len(resp.Users), resp.Pagination)
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+	return resp.Users, nextPageToken, c.rateLimitDescription(httpResp), nil
+}
+
+type CourseRef struct {
+	Id             string `xml:"Id"`
+	DueDate        Time   `xml:"DueDate"`
+	AccessTillDate Time   `xml:"AccessTillDate"`
+}
+type CourseRefsReq struct {
+	XMLName xml.Name    `xml:"Courses"`
+	Courses []CourseRef `xml:"Course"`
+}
+
+// CourseAssignment describes the enrollment of a user in a course. Null dates are left for Litmos to decide.
+type CourseAssignment struct {
+	CourseId       string
+	DueDate        Time
+	AccessTillDate Time
+	// SendMessage has Litmos email the learner about the assignment.
+	SendMessage bool
+}
+
+// AssignCourse enrolls the user in the course with the due date and access limit of the assignment.
+func (c *Client) AssignCourse(ctx context.Context, userId string, assignment *CourseAssignment) error {
+	path, err := url.JoinPath("/v1.svc/users", userId, "courses")
+	if err != nil {
+		return err
+	}
+	query := &url.Values{}
+	query.Add("sendmessage", strconv.FormatBool(assignment.SendMessage))
+	body := CourseRefsReq{
+		Courses: []CourseRef{{
+			Id:             assignment.CourseId,
+			DueDate:        assignment.DueDate,
+			AccessTillDate: assignment.AccessTillDate,
+		}},
+	}
+	_, err = c.Do(ctx, http.MethodPost, path, query, nil, withXMLBody(body))
+	return err
+}
+
+// RemoveCourseFromUser unenrolls the user from the course.
+func (c *Client) RemoveCourseFromUser(ctx context.Context, userId string, courseId string) error {
+	path, err := url.JoinPath("/v1.svc/users", userId, "courses", courseId)
+	if err != nil {
+		return err
 	}
-	_, err = c.Do(ctx, "GET", path, query, &resp)
+	_, err = c.Do(ctx, http.MethodDelete, path, nil, nil)
+	return err
+}
+
+// AssignCourseToTeam assigns the course to the team, which enrolls every member of the team.
+func (c *Client) AssignCourseToTeam(ctx context.Context, teamId string, courseId string) error {
+	path, err := url.JoinPath("/v1.svc/teams", teamId, "courses")
 	if err != nil {
-		return nil, pToken.Token, err
+		return err
+	}
+	body := CourseRefsReq{
+		Courses: []CourseRef{{Id: courseId}},
 	}
+	_, err = c.Do(ctx, http.MethodPost, path, nil, nil, withXMLBody(body))
+	return err
+}
 
-	nextPageToken := getNextPageToken(pToken, len(resp.Users))
-	return resp.Users, nextPageToken, nil
+// RemoveCourseFromTeam unassigns the course from the team.
+func (c *Client) RemoveCourseFromTeam(ctx context.Context, teamId string, courseId string) error {
+	path, err := url.JoinPath("/v1.svc/teams", teamId, "courses", courseId)
+	if err != nil {
+		return err
+	}
+	_, err = c.Do(ctx, http.MethodDelete, path, nil, nil)
+	return err
+}
+
+type LearningPath struct {
+	Id                        string `xml:"Id"`
+	Name                      string `xml:"Name"`
+	Description               string `xml:"Description"`
+	Active                    Bool   `xml:"Active"`
+	ForSale                   Bool   `xml:"ForSale"`
+	OriginalId                string `xml:"OriginalId"`
+	EcommerceShortDescription string `xml:"EcommerceShortDescription"`
+	EcommerceLongDescription  string `xml:"EcommerceLongDescription"`
+	Price                     string `xml:"Price"`
+	AccessTillDate            Time   `xml:"AccessTillDate"`
+	AccessTillDays            string `xml:"AccessTillDays"`
+}
+type LearningPathsResp struct {
+	LearningPaths []LearningPath  `xml:"LearningPath"`
+	Pagination    *PaginationInfo `xml:"Pagination"`
+}
+
+func (c *Client) ListLearningPaths(ctx context.Context, pToken *pagination.Token) ([]LearningPath, string, *v2.RateLimitDescription, error) {
+	learningPathsResp := LearningPathsResp{}
+	query := c.pageTokenToQuery(pToken)
+	httpResp, err := c.Do(ctx, "GET", "/v1.svc/learningpaths", query, &learningPathsResp)
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+
+	nextPageToken, err := c.getNextPageToken(pToken, len(learningPathsResp.LearningPaths), learningPathsResp.Pagination)
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+	return learningPathsResp.LearningPaths, nextPageToken, c.rateLimitDescription(httpResp), nil
+}
+
+type LearningPathUser struct {
+	Id                 string `xml:"Id"`
+	UserName           string `xml:"UserName"`
+	FirstName          string `xml:"FirstName"`
+	LastName           string `xml:"LastName"`
+	Completed          Bool   `xml:"Completed"`
+	PercentageComplete Float  `xml:"PercentageComplete"`
+}
+type LearningPathUsersResp struct {
+	XMLName    xml.Name           `xml:"Users"`
+	Users      []LearningPathUser `xml:"User"`
+	Pagination *PaginationInfo    `xml:"Pagination"`
+}
+
+func (c *Client) ListLearningPathUsers(ctx context.Context, pToken *pagination.Token, learningPathId string) ([]LearningPathUser, string, *v2.RateLimitDescription, error) {
+	resp := LearningPathUsersResp{}
+	query := c.pageTokenToQuery(pToken)
+	path, err := url.JoinPath("/v1.svc/learningpaths", learningPathId, "users")
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+	httpResp, err := c.Do(ctx, "GET", path, query, &resp)
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+
+	nextPageToken, err := c.getNextPageToken(pToken, len(resp.Users), resp.Pagination)
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+	return resp.Users, nextPageToken, c.rateLimitDescription(httpResp), nil
+}
+
+func (c *Client) ListLearningPathCourses(ctx context.Context, pToken *pagination.Token, learningPathId string) ([]Course, string, *v2.RateLimitDescription, error) {
+	coursesResp := CoursesResp{}
+	query := c.pageTokenToQuery(pToken)
+	path, err := url.JoinPath("/v1.svc/learningpaths", learningPathId, "courses")
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+	httpResp, err := c.Do(ctx, "GET", path, query, &coursesResp)
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+
+	nextPageToken, err := c.getNextPageToken(pToken, len(coursesResp.Courses), coursesResp.Pagination)
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+	return coursesResp.Courses, nextPageToken, c.rateLimitDescription(httpResp), nil
+}
+
+type LearningPathRef struct {
+	Id string `xml:"Id"`
+}
+type LearningPathRefsReq struct {
+	XMLName       xml.Name          `xml:"LearningPaths"`
+	LearningPaths []LearningPathRef `xml:"LearningPath"`
+}
+
+// AssignLearningPathToUser enrolls the user in the learning path and, through it, in each of its courses. Litmos is
+// told not to send the learner an assignment email.
+func (c *Client) AssignLearningPathToUser(ctx context.Context, userId string, learningPathId string) error {
+	path, err := url.JoinPath("/v1.svc/users", userId, "learningpaths")
+	if err != nil {
+		return err
+	}
+	query := &url.Values{}
+	query.Add("sendmessage", "false")
+	body := LearningPathRefsReq{
+		LearningPaths: []LearningPathRef{{Id: learningPathId}},
+	}
+	_, err = c.Do(ctx, http.MethodPost, path, query, nil, withXMLBody(body))
+	return err
+}
+
+func (c *Client) RemoveLearningPathFromUser(ctx context.Context, userId string, learningPathId string) error {
+	path, err := url.JoinPath("/v1.svc/users", userId, "learningpaths", learningPathId)
+	if err != nil {
+		return err
+	}
+	_, err = c.Do(ctx, http.MethodDelete, path, nil, nil)
+	return err
 }
 
 type Module struct {
@@ -275,21 +915,59 @@ type Module struct {
 	Description string `xml:"Description"`
 }
 type ModulesResp struct {
-	Modules []Module `xml:"Module"`
+	Modules    []Module        `xml:"Module"`
+	Pagination *PaginationInfo `xml:"Pagination"`
 }
 
-func (c *Client) ListModules(ctx context.Context, pToken *pagination.Token, courseId string) ([]Module, string, error) {
+func (c *Client) ListModules(ctx context.Context, pToken *pagination.Token, courseId string) ([]Module, string, *v2.RateLimitDescription, error) {
 	modulesResp := ModulesResp{}
-	query := pageTokenToQuery(pToken)
+	query := c.pageTokenToQuery(pToken)
 	path, err := url.JoinPath("/v1.svc/courses", courseId, "modules")
 	if err != nil {
-		return nil, pToken.Token, err
+		return nil, pToken.Token, nil, err
+	}
+	httpResp, err := c.Do(ctx, "GET", path, query, &modulesResp)
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+
+	nextPageToken, err := c.getNextPageToken(pToken, len(modulesResp.Modules), modulesResp.Pagination)
+	if err != nil {
+		return nil, pToken.Token, nil, err
+	}
+	return modulesResp.Modules, nextPageToken, c.rateLimitDescription(httpResp), nil
+}
+
+// ModuleResult is the progress of a single user on a module of a course.
+type ModuleResult struct {
+	Id            string `xml:"Id"`
+	Code          string `xml:"Code"`
+	Name          string `xml:"Name"`
+	Completed     Bool   `xml:"Completed"`
+	Passed        Bool   `xml:"Passed"`
+	Score         Float  `xml:"Score"`
+	PassMark      Float  `xml:"PassMark"`
+	Attempt       Int    `xml:"Attempt"`
+	StartDate     Time   `xml:"StartDate"`
+	DateCompleted Time   `xml:"DateCompleted"`
+}
+type UserCourseResp struct {
+	XMLName xml.Name       `xml:"Course"`
+	Id      string         `xml:"Id"`
+	Modules []ModuleResult `xml:"Modules>Module"`
+}
+
+// ListUserModuleResults returns the results of the user on every module of the course.
+func (c *Client) ListUserModuleResults(ctx context.Context, userId string, courseId string) ([]ModuleResult, error) {
+	resp := UserCourseResp{}
+	path, err := url.JoinPath("/v1.svc/users", userId, "courses", courseId)
+	if err != nil {
+		return nil, err
 	}
-	_, err = c.Do(ctx, "GET", path, query, &modulesResp)
+	_, err = c.Do(ctx, "GET", path, nil, &resp)
 	if err != nil {
-		return nil, pToken.Token, err
+		return nil, err
 	}
 
-	nextPageToken := getNextPageToken(pToken, len(modulesResp.Modules))
-	return modulesResp.Modules, nextPageToken, nil
+	return resp.Modules, nil
 }
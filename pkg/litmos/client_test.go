@@ -0,0 +1,187 @@
+package litmos
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+)
+
+// newTestClient returns a client for a fake Litmos API served by handler. Client-side rate limiting and retries are
+// turned off so tests run fast.
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	c, err := NewClient(context.Background(), "key", "test", WithBaseURL(srv.URL), WithRequestsPerMinute(-1), WithMaxRetries(-1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestSetUserActiveAfterUpdate(t *testing.T) {
+	record := readFixture(t, "user.xml")
+	var lastPut []byte
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "application/xml")
+			_, _ = w.Write(record)
+		case http.MethodPut:
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lastPut = body
+			record = body
+		}
+	}))
+	ctx := context.Background()
+
+	// Deactivating and reactivating within the cache TTL must send both updates.
+	for _, active := range []bool{false, true, false} {
+		lastPut = nil
+		err := c.SetUserActive(ctx, "u1", active)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lastPut == nil {
+			t.Fatalf("SetUserActive(%v) sent no update", active)
+		}
+		var sent User
+		err = xml.Unmarshal(lastPut, &sent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !sent.Active.Valid || sent.Active.Bool != active {
+			t.Fatalf("SetUserActive(%v) sent Active %+v", active, sent.Active)
+		}
+	}
+}
+
+func TestUpdateUserKeepsUnmodeledElements(t *testing.T) {
+	var user User
+	err := xml.Unmarshal(readFixture(t, "user.xml"), &user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := xml.Marshal(&user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, element := range []string{
+		"<Skype>ada.lovelace</Skype>",
+		"<Website>https://example.com/ada</Website>",
+		"<Points>120</Points>",
+		"<Name>Cost Center</Name>",
+		"<LastLogin>/Date(1700000000000+0100)/</LastLogin>",
+	} {
+		if !strings.Contains(string(b), element) {
+			t.Errorf("marshaled user is missing %s: %s", element, b)
+		}
+	}
+}
+
+func TestGetNextPageToken(t *testing.T) {
+	c := &Client{pageSize: 3}
+	tests := []struct {
+		name     string
+		token    string
+		numItems int
+		info     *PaginationInfo
+		want     string
+		wantErr  bool
+	}{
+		{name: "envelope, more pages", token: "3", numItems: 3, info: &PaginationInfo{BatchSize: 3, Start: 3, TotalCount: 10}, want: "6"},
+		{name: "envelope, last page", token: "9", numItems: 1, info: &PaginationInfo{BatchSize: 3, Start: 9, TotalCount: 10}},
+		{name: "envelope, server caps the page size", numItems: 2, info: &PaginationInfo{BatchSize: 3, Start: 0, TotalCount: 10}, want: "2"},
+		{name: "envelope, empty page", token: "3", info: &PaginationInfo{BatchSize: 3, Start: 3, TotalCount: 10}},
+		{name: "envelope, server ignores start", token: "3", numItems: 3, info: &PaginationInfo{BatchSize: 3, Start: 0, TotalCount: 10}, wantErr: true},
+		{name: "no envelope, full page", token: "3", numItems: 3, want: "6"},
+		{name: "no envelope, short page", token: "3", numItems: 2},
+		{name: "no envelope, empty page", token: "3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.getNextPageToken(&pagination.Token{Token: tt.token}, tt.numItems, tt.info)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, want error %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("next page token %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssignCourseBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		assignment  CourseAssignment
+		sendMessage string
+		body        string
+	}{
+		{
+			name: "dates",
+			assignment: CourseAssignment{
+				CourseId:       "c1",
+				DueDate:        NewTime(time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)),
+				AccessTillDate: NewTime(time.Date(2024, 12, 31, 12, 0, 0, 0, time.UTC)),
+				SendMessage:    true,
+			},
+			sendMessage: "true",
+			body:        `<Courses><Course><Id>c1</Id><DueDate>2024-07-01T00:00:00</DueDate><AccessTillDate>2024-12-31T12:00:00</AccessTillDate></Course></Courses>`,
+		},
+		{
+			name:        "no dates",
+			assignment:  CourseAssignment{CourseId: "c1"},
+			sendMessage: "false",
+			body:        `<Courses><Course><Id>c1</Id></Course></Courses>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path, sendMessage, body string
+			c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, err := io.ReadAll(r.Body)
+				if err != nil {
+					t.Error(err)
+				}
+				path, sendMessage, body = r.URL.Path, r.URL.Query().Get("sendmessage"), string(b)
+			}))
+
+			err := c.AssignCourse(context.Background(), "u1", &tt.assignment)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "/v1.svc/users/u1/courses"; path != want {
+				t.Errorf("path %s, want %s", path, want)
+			}
+			if sendMessage != tt.sendMessage {
+				t.Errorf("sendmessage=%s, want %s", sendMessage, tt.sendMessage)
+			}
+			if body != tt.body {
+				t.Errorf("body %s, want %s", body, tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package litmos
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Litmos sends empty elements such as <Active/> or <Score></Score> for values it does not have. The types below decode
+// those, and values that cannot be parsed, as null instead of failing the whole response. Valid is false for null.
+
+// Bool is a boolean element that may be empty.
+type Bool struct {
+	Bool  bool
+	Valid bool
+}
+
+// NewBool returns a non-null Bool.
+func NewBool(b bool) Bool {
+	return Bool{Bool: b, Valid: true}
+}
+
+func (b *Bool) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	value, err := decodeElementText(d, start)
+	if err != nil {
+		return err
+	}
+	*b = Bool{}
+	if parsed, err := strconv.ParseBool(value); err == nil {
+		*b = NewBool(parsed)
+	}
+	return nil
+}
+
+// MarshalXML omits the element when the value is null, so that Litmos keeps its current value.
+func (b Bool) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if !b.Valid {
+		return nil
+	}
+	return e.EncodeElement(strconv.FormatBool(b.Bool), start)
+}
+
+// Value returns the boolean, or nil when it is null.
+func (b Bool) Value() interface{} {
+	if !b.Valid {
+		return nil
+	}
+	return b.Bool
+}
+
+// Float is a numeric element that may be empty.
+type Float struct {
+	Float64 float64
+	Valid   bool
+}
+
+func (f *Float) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	value, err := decodeElementText(d, start)
+	if err != nil {
+		return err
+	}
+	*f = Float{}
+	if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+		*f = Float{Float64: parsed, Valid: true}
+	}
+	return nil
+}
+
+func (f Float) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if !f.Valid {
+		return nil
+	}
+	return e.EncodeElement(strconv.FormatFloat(f.Float64, 'f', -1, 64), start)
+}
+
+// Value returns the number, or nil when it is null.
+func (f Float) Value() interface{} {
+	if !f.Valid {
+		return nil
+	}
+	return f.Float64
+}
+
+// Int is an integer element that may be empty.
+type Int struct {
+	Int64 int64
+	Valid bool
+}
+
+func (i *Int) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	value, err := decodeElementText(d, start)
+	if err != nil {
+		return err
+	}
+	*i = Int{}
+	if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+		*i = Int{Int64: parsed, Valid: true}
+	}
+	return nil
+}
+
+func (i Int) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if !i.Valid {
+		return nil
+	}
+	return e.EncodeElement(strconv.FormatInt(i.Int64, 10), start)
+}
+
+// Value returns the number, or nil when it is null.
+func (i Int) Value() interface{} {
+	if !i.Valid {
+		return nil
+	}
+	return i.Int64
+}
+
+// Time is a timestamp element that may be empty. Raw keeps the value as Litmos sent it, also when it could not be
+// parsed.
+type Time struct {
+	Time  time.Time
+	Raw   string
+	Valid bool
+}
+
+// NewTime returns a non-null Time, formatted the way Litmos accepts dates in request bodies.
+func NewTime(t time.Time) Time {
+	return Time{Time: t, Raw: t.UTC().Format("2006-01-02T15:04:05"), Valid: true}
+}
+
+func (t *Time) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	value, err := decodeElementText(d, start)
+	if err != nil {
+		return err
+	}
+	*t = Time{Raw: value}
+	// Litmos reports a missing date as 0001-01-01T00:00:00 in some places.
+	if parsed, ok := ParseTime(value); ok && !parsed.IsZero() {
+		t.Time = parsed
+		t.Valid = true
+	}
+	return nil
+}
+
+func (t Time) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if t.Raw == "" {
+		return nil
+	}
+	return e.EncodeElement(t.Raw, start)
+}
+
+// Value returns the timestamp formatted as RFC 3339 in UTC, or nil when it is null.
+func (t Time) Value() interface{} {
+	if !t.Valid {
+		return nil
+	}
+	return t.Time.UTC().Format(time.RFC3339)
+}
+
+// decodeElementText returns the trimmed text of the element, or an empty string when it is marked as nil.
+func decodeElementText(d *xml.Decoder, start xml.StartElement) (string, error) {
+	var value string
+	err := d.DecodeElement(&value, &start)
+	if err != nil {
+		return "", err
+	}
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "nil" && attr.Value == "true" {
+			return "", nil
+		}
+	}
+	return strings.TrimSpace(value), nil
+}
@@ -0,0 +1,251 @@
+package litmos
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+)
+
+type nullableRecord struct {
+	Name  string `xml:"Name"`
+	Bool  Bool   `xml:"Bool"`
+	Float Float  `xml:"Float"`
+	Int   Int    `xml:"Int"`
+	Time  Time   `xml:"Time"`
+}
+
+func TestNullableUnmarshal(t *testing.T) {
+	var records struct {
+		Records []nullableRecord `xml:"Record"`
+	}
+	err := xml.Unmarshal(readFixture(t, "nullable.xml"), &records)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byName := make(map[string]nullableRecord)
+	for _, r := range records.Records {
+		byName[r.Name] = r
+	}
+
+	tests := []struct {
+		name  string
+		bool  interface{}
+		float interface{}
+		int   interface{}
+		time  interface{}
+	}{
+		{"values", true, 12.5, int64(3), "2019-04-01T09:30:00Z"},
+		{"padded values", false, -0.25, int64(0), "2019-04-01T00:00:00Z"},
+		{"self-closing", nil, nil, nil, nil},
+		{"empty", nil, nil, nil, nil},
+		{"i:nil", nil, nil, nil, nil},
+		{"xsi:nil", nil, nil, nil, nil},
+		{"malformed", nil, nil, nil, nil},
+		{"out of range", nil, nil, nil, nil},
+		{"absent", nil, nil, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := byName[tt.name]
+			if !ok {
+				t.Fatalf("fixture has no record %q", tt.name)
+			}
+			if got := r.Bool.Value(); got != tt.bool {
+				t.Errorf("Bool = %v, want %v", got, tt.bool)
+			}
+			if got := r.Float.Value(); got != tt.float {
+				t.Errorf("Float = %v, want %v", got, tt.float)
+			}
+			if got := r.Int.Value(); got != tt.int {
+				t.Errorf("Int = %v, want %v", got, tt.int)
+			}
+			if got := r.Time.Value(); got != tt.time {
+				t.Errorf("Time = %v, want %v", got, tt.time)
+			}
+		})
+	}
+
+	// Raw keeps a date Litmos sent even when it could not be parsed.
+	if raw := byName["malformed"].Time.Raw; raw != "yesterday" {
+		t.Errorf("Raw = %q, want %q", raw, "yesterday")
+	}
+}
+
+func TestNullableMarshal(t *testing.T) {
+	record := nullableRecord{
+		Name:  "values",
+		Bool:  NewBool(false),
+		Float: Float{Float64: 12.5, Valid: true},
+		Int:   Int{Int64: 3, Valid: true},
+		Time:  NewTime(time.Date(2019, 4, 1, 11, 30, 0, 0, time.FixedZone("", 2*60*60))),
+	}
+	b, err := xml.Marshal(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<nullableRecord><Name>values</Name><Bool>false</Bool><Float>12.5</Float><Int>3</Int><Time>2019-04-01T09:30:00</Time></nullableRecord>`
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+
+	// Null values are left out, so that Litmos keeps its current value.
+	b, err = xml.Marshal(nullableRecord{Name: "null"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = `<nullableRecord><Name>null</Name></nullableRecord>`
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+}
+
+// fixtureHandler serves the testdata file mapped to the request path.
+func fixtureHandler(t *testing.T, fixtures map[string]string) http.Handler {
+	t.Helper()
+	bodies := make(map[string][]byte, len(fixtures))
+	for path, name := range fixtures {
+		bodies[path] = readFixture(t, name)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := bodies[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = w.Write(body)
+	})
+}
+
+func TestListUsersDecodesNullElements(t *testing.T) {
+	c := newTestClient(t, fixtureHandler(t, map[string]string{"/v1.svc/users": "users.xml"}))
+
+	users, _, _, err := c.ListUsers(context.Background(), &pagination.Token{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 3 {
+		t.Fatalf("got %d users, want 3", len(users))
+	}
+
+	tests := []struct {
+		id     string
+		active interface{}
+		email  string
+		brand  string
+	}{
+		{"u1", true, "ada@example.com", "Default"},
+		{"u2", nil, "", ""},
+		{"u3", nil, "alan@example.com", "Default"},
+	}
+	for i, tt := range tests {
+		u := users[i]
+		if u.Id != tt.id {
+			t.Fatalf("user %d is %s, want %s", i, u.Id, tt.id)
+		}
+		if got := u.Active.Value(); got != tt.active {
+			t.Errorf("%s: Active = %v, want %v", u.Id, got, tt.active)
+		}
+		if u.Email != tt.email {
+			t.Errorf("%s: Email = %q, want %q", u.Id, u.Email, tt.email)
+		}
+		if u.Brand != tt.brand {
+			t.Errorf("%s: Brand = %q, want %q", u.Id, u.Brand, tt.brand)
+		}
+	}
+}
+
+func TestListCoursesDecodesNullElements(t *testing.T) {
+	c := newTestClient(t, fixtureHandler(t, map[string]string{"/v1.svc/courses": "courses.xml"}))
+
+	courses, _, _, err := c.ListCourses(context.Background(), &pagination.Token{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(courses) != 2 {
+		t.Fatalf("got %d courses, want 2", len(courses))
+	}
+
+	tests := []struct {
+		id                string
+		active            interface{}
+		forSale           interface{}
+		accessTillDate    interface{}
+		courseTeamLibrary interface{}
+	}{
+		{"c1", true, false, "2025-12-31T00:00:00Z", true},
+		{"c2", nil, nil, nil, nil},
+	}
+	for i, tt := range tests {
+		course := courses[i]
+		if course.Id != tt.id {
+			t.Fatalf("course %d is %s, want %s", i, course.Id, tt.id)
+		}
+		if got := course.Active.Value(); got != tt.active {
+			t.Errorf("%s: Active = %v, want %v", course.Id, got, tt.active)
+		}
+		if got := course.ForSale.Value(); got != tt.forSale {
+			t.Errorf("%s: ForSale = %v, want %v", course.Id, got, tt.forSale)
+		}
+		if got := course.AccessTillDate.Value(); got != tt.accessTillDate {
+			t.Errorf("%s: AccessTillDate = %v, want %v", course.Id, got, tt.accessTillDate)
+		}
+		if got := course.CourseTeamLibrary.Value(); got != tt.courseTeamLibrary {
+			t.Errorf("%s: CourseTeamLibrary = %v, want %v", course.Id, got, tt.courseTeamLibrary)
+		}
+	}
+}
+
+func TestListCourseUsersDecodesNullElements(t *testing.T) {
+	c := newTestClient(t, fixtureHandler(t, map[string]string{"/v1.svc/courses/c1/users": "course_users.xml"}))
+
+	users, next, _, err := c.ListCourseUsers(context.Background(), &pagination.Token{}, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != "" {
+		t.Errorf("next page token %q, want none", next)
+	}
+	if len(users) != 3 {
+		t.Fatalf("got %d users, want 3", len(users))
+	}
+
+	tests := []struct {
+		id                 string
+		completed          interface{}
+		percentageComplete interface{}
+		compliantTill      interface{}
+		dueDate            interface{}
+		accessTillDate     interface{}
+	}{
+		{"u1", true, float64(100), "2025-06-30T00:00:00Z", "2024-12-31T00:00:00Z", nil},
+		{"u2", false, nil, nil, nil, "2025-03-01T00:00:00Z"},
+		{"u3", nil, nil, nil, nil, nil},
+	}
+	for i, tt := range tests {
+		u := users[i]
+		if u.Id != tt.id {
+			t.Fatalf("user %d is %s, want %s", i, u.Id, tt.id)
+		}
+		if got := u.Completed.Value(); got != tt.completed {
+			t.Errorf("%s: Completed = %v, want %v", u.Id, got, tt.completed)
+		}
+		if got := u.PercentageComplete.Value(); got != tt.percentageComplete {
+			t.Errorf("%s: PercentageComplete = %v, want %v", u.Id, got, tt.percentageComplete)
+		}
+		if got := u.CompliantTill.Value(); got != tt.compliantTill {
+			t.Errorf("%s: CompliantTill = %v, want %v", u.Id, got, tt.compliantTill)
+		}
+		if got := u.DueDate.Value(); got != tt.dueDate {
+			t.Errorf("%s: DueDate = %v, want %v", u.Id, got, tt.dueDate)
+		}
+		if got := u.AccessTillDate.Value(); got != tt.accessTillDate {
+			t.Errorf("%s: AccessTillDate = %v, want %v", u.Id, got, tt.accessTillDate)
+		}
+	}
+}
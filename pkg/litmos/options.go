@@ -0,0 +1,101 @@
+package litmos
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const defaultBaseURL = "https://api.litmos.com"
+
+// regionBaseURLs maps the region presets accepted as a base URL to the API host of that Litmos data center.
+var regionBaseURLs = map[string]string{
+	"us": defaultBaseURL,
+	"eu": "https://api.litmoseu.com",
+	"au": "https://api.litmos.com.au",
+}
+
+type Option func(c *Client) error
+
+// ParseBaseURL resolves a region preset (us, eu, au) or an absolute http(s) URL to the base URL of the Litmos API.
+// An empty value selects the US data center.
+func ParseBaseURL(baseURL string) (*url.URL, error) {
+	baseURL = strings.TrimSpace(baseURL)
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	if preset, ok := regionBaseURLs[strings.ToLower(baseURL)]; ok {
+		baseURL = preset
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL %q: %w", baseURL, err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme must be http or https", baseURL)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q: missing host", baseURL)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("invalid base URL %q: query and fragment are not allowed", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	return u, nil
+}
+
+// WithBaseURL points the client at another Litmos data center or at a local stand-in for the API.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) error {
+		u, err := ParseBaseURL(baseURL)
+		if err != nil {
+			return err
+		}
+		c.baseURL = u
+		return nil
+	}
+}
+
+// WithPageSize sets the number of items requested per page, between 1 and 1,000. Zero keeps the default of 500.
+func WithPageSize(pageSize int) Option {
+	return func(c *Client) error {
+		if pageSize == 0 {
+			return nil
+		}
+		if pageSize < 0 || pageSize > maxPageSize {
+			return fmt.Errorf("invalid page size %d: must be between 1 and %d", pageSize, maxPageSize)
+		}
+		c.pageSize = pageSize
+		return nil
+	}
+}
+
+// WithRequestsPerMinute limits how many requests the client sends to Litmos per minute. A negative value disables the
+// client-side limit, zero keeps the default of 100.
+func WithRequestsPerMinute(requestsPerMinute int) Option {
+	return func(c *Client) error {
+		switch {
+		case requestsPerMinute < 0:
+			c.limiter = nil
+		case requestsPerMinute > 0:
+			c.limiter = newTokenBucket(requestsPerMinute)
+		}
+		return nil
+	}
+}
+
+// WithMaxRetries sets how many times a read that failed with a transient error is sent again. A negative value
+// disables retries, zero keeps the default of 3.
+func WithMaxRetries(maxRetries int) Option {
+	return func(c *Client) error {
+		switch {
+		case maxRetries < 0:
+			c.retryPolicy.maxRetries = 0
+		case maxRetries > 0:
+			c.retryPolicy.maxRetries = maxRetries
+		}
+		return nil
+	}
+}
@@ -0,0 +1,129 @@
+package litmos
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/ratelimit"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const defaultRequestsPerMinute = 100
+
+// How long to back off after a 429 that came without a usable Retry-After header. Litmos quotas are per minute.
+const defaultRetryAfter = time.Minute
+
+// How many times a request rejected with a 429 is sent again before the error is returned.
+const maxRateLimitRetries = 3
+
+// The longest we wait on a single Retry-After, so that a far off date from the server cannot stall the sync.
+const maxRetryAfter = defaultRetryAfter * maxRateLimitRetries
+
+// tokenBucket allows bursts of up to one minute worth of requests, refilled continuously at the configured rate.
+type tokenBucket struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	perToken time.Duration
+	last     time.Time
+}
+
+func newTokenBucket(requestsPerMinute int) *tokenBucket {
+	return &tokenBucket{
+		capacity: float64(requestsPerMinute),
+		tokens:   float64(requestsPerMinute),
+		perToken: time.Minute / time.Duration(requestsPerMinute),
+		last:     time.Now(),
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens += float64(now.Sub(b.last)) / float64(b.perToken)
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+}
+
+// Wait blocks until a request may be sent or the context is done.
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		b.refill(time.Now())
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - b.tokens) * float64(b.perToken))
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Drain empties the bucket, so that requests sent after Litmos reported the quota as exhausted are spread out again.
+func (b *tokenBucket) Drain() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	b.tokens = 0
+}
+
+// Describe reports the state of the bucket in the form the SDK uses for rate limit annotations.
+func (b *tokenBucket) Describe() *v2.RateLimitDescription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.refill(now)
+
+	status := v2.RateLimitDescription_STATUS_OK
+	if b.tokens < 1 {
+		status = v2.RateLimitDescription_STATUS_OVERLIMIT
+	}
+	return &v2.RateLimitDescription{
+		Status:    status,
+		Limit:     int64(b.capacity),
+		Remaining: int64(b.tokens),
+		ResetAt:   timestamppb.New(now.Add(time.Duration((b.capacity - b.tokens) * float64(b.perToken)))),
+	}
+}
+
+// retryAfter returns how long Litmos asked us to wait before sending the request again, at most maxRetryAfter.
+// Retry-After is either a number of seconds or an HTTP date.
+func retryAfter(resp *http.Response) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return defaultRetryAfter
+	}
+	wait := defaultRetryAfter
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		wait = min(time.Duration(seconds), maxRetryAfter/time.Second) * time.Second
+	} else if at, err := http.ParseTime(value); err == nil {
+		wait = max(time.Until(at), 0)
+	}
+	return min(wait, maxRetryAfter)
+}
+
+// rateLimitDescription describes the quota after the response. Rate limit headers sent by Litmos take precedence over
+// the state of the client-side limiter.
+func (c *Client) rateLimitDescription(resp *http.Response) *v2.RateLimitDescription {
+	if resp != nil {
+		desc, err := ratelimit.ExtractRateLimitData(resp.StatusCode, &resp.Header)
+		if err == nil && desc != nil && desc.Status != v2.RateLimitDescription_STATUS_UNSPECIFIED {
+			return desc
+		}
+	}
+	if c.limiter == nil {
+		return nil
+	}
+	return c.limiter.Describe()
+}
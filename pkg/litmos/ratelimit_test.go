@@ -0,0 +1,104 @@
+package litmos
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+)
+
+func TestTokenBucket(t *testing.T) {
+	b := newTokenBucket(60)
+	if desc := b.Describe(); desc.Status != v2.RateLimitDescription_STATUS_OK || desc.Limit != 60 || desc.Remaining != 60 {
+		t.Fatalf("new bucket is %v, want 60 of 60 requests left", desc)
+	}
+
+	b.Drain()
+	if desc := b.Describe(); desc.Status != v2.RateLimitDescription_STATUS_OVERLIMIT {
+		t.Fatalf("drained bucket is %v, want it over the limit", desc)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.Wait(ctx); err == nil {
+		t.Fatal("Wait on a drained bucket returned before a token was refilled")
+	}
+
+	b.mu.Lock()
+	b.last = b.last.Add(-1500 * time.Millisecond)
+	b.mu.Unlock()
+	if err := b.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if desc := b.Describe(); desc.Status != v2.RateLimitDescription_STATUS_OVERLIMIT {
+		t.Errorf("bucket is %v after spending the refilled token, want it over the limit", desc)
+	}
+
+	b.refill(b.last.Add(time.Hour))
+	if b.tokens != b.capacity {
+		t.Errorf("bucket holds %v tokens after an hour, want the capacity %v", b.tokens, b.capacity)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "missing", want: defaultRetryAfter},
+		{name: "seconds", value: "30", want: 30 * time.Second},
+		{name: "zero seconds", value: "0", want: 0},
+		{name: "seconds past the cap", value: strconv.Itoa(int(maxRetryAfter/time.Second) + 1), want: maxRetryAfter},
+		{name: "huge seconds", value: "9223372036854775807", want: maxRetryAfter},
+		{name: "date in the past", value: "Sun, 06 Nov 1994 08:49:37 GMT", want: 0},
+		{name: "date past the cap", value: time.Now().Add(24 * time.Hour).UTC().Format(http.TimeFormat), want: maxRetryAfter},
+		{name: "malformed", value: "soon", want: defaultRetryAfter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tt.value != "" {
+				resp.Header.Set("Retry-After", tt.value)
+			}
+			if got := retryAfter(resp); got != tt.want {
+				t.Errorf("retryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+
+	at := time.Now().Add(10 * time.Second).UTC()
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{at.Format(http.TimeFormat)}}}
+	if got := retryAfter(resp); got <= 0 || got > 10*time.Second {
+		t.Errorf("retryAfter(%s) = %v, want up to 10s", at.Format(http.TimeFormat), got)
+	}
+}
+
+func TestTooManyRequestsIsRetried(t *testing.T) {
+	var hits atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = w.Write([]byte(usersBody))
+	}))
+
+	users, _, _, err := c.ListUsers(context.Background(), &pagination.Token{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("got %d users, want 2", len(users))
+	}
+	if got := hits.Load(); got != 2 {
+		t.Fatalf("got %d requests, want 2", got)
+	}
+}
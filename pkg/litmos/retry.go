@@ -0,0 +1,75 @@
+package litmos
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const defaultMaxRetries = 3
+
+// retryPolicy controls how idempotent requests are sent again after a transient failure. The delay doubles after every
+// attempt up to maxDelay, with full jitter, and no retry is scheduled past deadline, counted from the first attempt.
+type retryPolicy struct {
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	deadline   time.Duration
+}
+
+func newRetryPolicy(maxRetries int) retryPolicy {
+	return retryPolicy{
+		maxRetries: maxRetries,
+		baseDelay:  time.Second,
+		maxDelay:   30 * time.Second,
+		deadline:   2 * time.Minute,
+	}
+}
+
+// backoff returns the delay before the given retry, starting at zero.
+func (p retryPolicy) backoff(retry int) time.Duration {
+	delay := p.baseDelay << retry
+	if delay <= 0 || delay > p.maxDelay {
+		delay = p.maxDelay
+	}
+	//nolint:gosec // Jitter does not need a cryptographically secure source.
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// transientFailure reports whether a failed request is worth sending again, and why. Only failures where Litmos or the
+// network gave up part way are retried, never answers that would come back the same.
+func transientFailure(resp *http.Response, err error) (string, bool) {
+	if resp != nil {
+		switch resp.StatusCode {
+		case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return resp.Status, true
+		}
+	}
+
+	switch {
+	case errors.Is(err, io.ErrUnexpectedEOF), truncatedBody(resp, err):
+		return "truncated response body", true
+	case errors.Is(err, syscall.ECONNRESET):
+		return "connection reset", true
+	case errors.Is(err, io.EOF):
+		return "connection closed", true
+	case status.Code(err) == codes.DeadlineExceeded && resp == nil:
+		return "request timeout", true
+	}
+
+	return "", false
+}
+
+// truncatedBody reports whether a 200 failed because its XML body was cut off. The HTTP client reads such a body
+// without error, and caches the response, before it fails to decode.
+func truncatedBody(resp *http.Response, err error) bool {
+	var syntaxErr *xml.SyntaxError
+	return resp != nil && resp.StatusCode == http.StatusOK && errors.As(err, &syntaxErr)
+}
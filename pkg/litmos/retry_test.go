@@ -0,0 +1,63 @@
+package litmos
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+)
+
+const usersBody = `<Users><User><Id>u1</Id><UserName>ada</UserName></User><User><Id>u2</Id><UserName>grace</UserName></User></Users>`
+
+// truncatingHandler answers the first n requests with a 200 whose body is cut off, and the rest in full.
+func truncatingHandler(n int32, hits *atomic.Int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		if hits.Add(1) <= n {
+			_, _ = w.Write([]byte(usersBody[:len(usersBody)/2]))
+			return
+		}
+		_, _ = w.Write([]byte(usersBody))
+	})
+}
+
+func TestTruncatedBodyIsRetried(t *testing.T) {
+	var hits atomic.Int32
+	c := newTestClient(t, truncatingHandler(1, &hits))
+	c.retryPolicy.maxRetries = 2
+	c.retryPolicy.baseDelay = time.Millisecond
+
+	users, _, _, err := c.ListUsers(context.Background(), &pagination.Token{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("got %d users, want 2", len(users))
+	}
+	if got := hits.Load(); got != 2 {
+		t.Fatalf("got %d requests, want 2", got)
+	}
+}
+
+func TestTruncatedBodyIsNotServedFromCache(t *testing.T) {
+	var hits atomic.Int32
+	c := newTestClient(t, truncatingHandler(1, &hits))
+
+	if _, _, _, err := c.ListUsers(context.Background(), &pagination.Token{}); err == nil {
+		t.Fatal("expected the truncated body to fail without retries")
+	}
+
+	users, _, _, err := c.ListUsers(context.Background(), &pagination.Token{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("got %d users, want 2", len(users))
+	}
+	if got := hits.Load(); got != 2 {
+		t.Fatalf("got %d requests, want 2", got)
+	}
+}
@@ -0,0 +1,55 @@
+package litmos
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// msDatePattern matches the Microsoft JSON date format, /Date(1700000000000)/ or /Date(1700000000000+0100)/. The
+// number is milliseconds since the Unix epoch in UTC, the optional offset is the time zone the value was recorded in.
+var msDatePattern = regexp.MustCompile(`^/Date\((-?\d+)([+-]\d{4})?\)/$`)
+
+// timeLayouts are the other formats Litmos uses for dates. Values without a zone are in UTC.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"1/2/2006 3:04:05 PM",
+	"1/2/2006 15:04:05",
+	"1/2/2006",
+}
+
+// ParseTime parses a date in any of the formats Litmos emits.
+func ParseTime(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	if m := msDatePattern.FindStringSubmatch(value); m != nil {
+		ms, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		t := time.UnixMilli(ms).UTC()
+		if m[2] != "" {
+			hours, _ := strconv.Atoi(m[2][1:3])
+			minutes, _ := strconv.Atoi(m[2][3:5])
+			offset := hours*60*60 + minutes*60
+			if m[2][0] == '-' {
+				offset = -offset
+			}
+			t = t.In(time.FixedZone("", offset))
+		}
+		return t, true
+	}
+
+	for _, layout := range timeLayouts {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
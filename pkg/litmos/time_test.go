@@ -0,0 +1,68 @@
+package litmos
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	utc := func(year int, month time.Month, day, hour, minute, sec, nsec int) time.Time {
+		return time.Date(year, month, day, hour, minute, sec, nsec, time.UTC)
+	}
+
+	tests := []struct {
+		value  string
+		want   time.Time
+		offset int
+		ok     bool
+	}{
+		{"/Date(1700000000000)/", utc(2023, 11, 14, 22, 13, 20, 0), 0, true},
+		{"/Date(1700000000000+0100)/", utc(2023, 11, 14, 22, 13, 20, 0), 60 * 60, true},
+		{"/Date(1700000000000-0530)/", utc(2023, 11, 14, 22, 13, 20, 0), -(5*60 + 30) * 60, true},
+		{"/Date(-86400000)/", utc(1969, 12, 31, 0, 0, 0, 0), 0, true},
+		{"2019-04-01T09:30:00Z", utc(2019, 4, 1, 9, 30, 0, 0), 0, true},
+		{"2019-04-01T09:30:00.123+02:00", utc(2019, 4, 1, 7, 30, 0, 123000000), 2 * 60 * 60, true},
+		{"2019-04-01T09:30:00", utc(2019, 4, 1, 9, 30, 0, 0), 0, true},
+		{"2019-04-01T09:30:00.5", utc(2019, 4, 1, 9, 30, 0, 500000000), 0, true},
+		{"2019-04-01 09:30:00", utc(2019, 4, 1, 9, 30, 0, 0), 0, true},
+		{"2019-04-01", utc(2019, 4, 1, 0, 0, 0, 0), 0, true},
+		{"4/1/2019 9:30:00 PM", utc(2019, 4, 1, 21, 30, 0, 0), 0, true},
+		{"4/1/2019 09:30:00", utc(2019, 4, 1, 9, 30, 0, 0), 0, true},
+		{"4/1/2019", utc(2019, 4, 1, 0, 0, 0, 0), 0, true},
+		{"", time.Time{}, 0, false},
+		{"/Date(abc)/", time.Time{}, 0, false},
+		{"/Date(1700000000000+01)/", time.Time{}, 0, false},
+		{"2019-13-01", time.Time{}, 0, false},
+		{"yesterday", time.Time{}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := ParseTime(tt.value)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			if _, offset := got.Zone(); offset != tt.offset {
+				t.Errorf("zone offset = %d, want %d", offset, tt.offset)
+			}
+
+			// The same value decodes as a non-null Time element.
+			var decoded struct {
+				Time Time `xml:"Time"`
+			}
+			err := xml.Unmarshal([]byte("<Record><Time>"+tt.value+"</Time></Record>"), &decoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !decoded.Time.Valid || !decoded.Time.Time.Equal(tt.want) {
+				t.Errorf("decoded %+v, want %v", decoded.Time, tt.want)
+			}
+		})
+	}
+}